@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// aliasNamePattern matches names Telegram accepts as commands
+var aliasNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// setAlias handles /alias: no arguments lists the chat's aliases, "<name>
+// <command>" adds one and "<name> off" removes it
+func setAlias(store *preferenceStore, chatID int64, args string) string {
+	fields := strings.Fields(strings.ToLower(args))
+	for i, f := range fields {
+		fields[i] = strings.TrimPrefix(f, "/")
+	}
+
+	switch len(fields) {
+	case 0:
+		return formatAliases(store.get(chatID).Aliases)
+	case 2:
+	default:
+		return "Usage: /alias <name> <command> to add one, /alias <name> off to remove it, or /alias to list them"
+	}
+
+	name, target := fields[0], fields[1]
+	if !aliasNamePattern.MatchString(name) {
+		return "Alias names can only use a-z, 0-9 and _, up to 32 characters."
+	}
+
+	if target == "off" {
+		if _, ok := store.get(chatID).Aliases[name]; !ok {
+			return fmt.Sprintf("You don't have an alias /%s.", name)
+		}
+		if err := store.update(chatID, func(p *ChatPreferences) {
+			delete(p.Aliases, name)
+		}); err != nil {
+			log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+			return "Sorry, your alias couldn't be removed. Please try again later."
+		}
+		return fmt.Sprintf("Removed the alias /%s.", name)
+	}
+
+	if _, exists := commands.lookup(name); exists {
+		return fmt.Sprintf("/%s is already a command.", name)
+	}
+	cmd, ok := commands.lookup(target)
+	if !ok || (cmd.Spec().Role == roleAdmin && !isAdmin(chatID)) {
+		return fmt.Sprintf("/%s isn't a command. Try /help", target)
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) {
+		if p.Aliases == nil {
+			p.Aliases = map[string]string{}
+		}
+		p.Aliases[name] = cmd.Spec().Name
+	}); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your alias couldn't be saved. Please try again later."
+	}
+	return fmt.Sprintf("/%s now runs /%s.", name, cmd.Spec().Name)
+}
+
+// formatAliases lists the chat's aliases for /alias
+func formatAliases(aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return "You have no aliases. Add one with /alias <name> <command>, e.g. /alias v vbucks"
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result strings.Builder
+	result.WriteString("Your aliases:\n")
+	for _, name := range names {
+		result.WriteString(fmt.Sprintf("/%s → /%s\n", name, aliases[name]))
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
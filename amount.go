@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// missionAmount reads a mission's reward, falling back to its digits for
+// rewards that aren't a plain number
+func missionAmount(m VBucksMission) int {
+	if amount, ok := m.amountValue(); ok {
+		return amount
+	}
+	return estimateAmount(m.Amount)
+}
+
+// showAmountMissions handles /amount <n>: today's missions rewarding exactly
+// n V-Bucks, or the amounts on offer when none do
+func showAmountMissions(t *tenant, chatID int64, args string) {
+	want, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil || want < 1 {
+		reply(t.bot, chatID, "Usage: /amount <V-Bucks>, e.g. /amount 50")
+		return
+	}
+
+	missions, _ := getMissions()
+	missions = filterForChat(t.prefs.get(chatID), missions)
+
+	var matching []VBucksMission
+	seen := map[int]bool{}
+	var amounts []int
+	for _, mission := range missions {
+		amount := missionAmount(mission)
+		if amount == want {
+			matching = append(matching, mission)
+		}
+		if !seen[amount] {
+			seen[amount] = true
+			amounts = append(amounts, amount)
+		}
+	}
+
+	if len(matching) == 0 {
+		if len(amounts) == 0 {
+			reply(t.bot, chatID, "No V-Bucks missions today.")
+			return
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(amounts)))
+		available := make([]string, len(amounts))
+		for i, amount := range amounts {
+			available[i] = strconv.Itoa(amount)
+		}
+		reply(t.bot, chatID, fmt.Sprintf("No missions reward exactly %d V-Bucks today. Amounts on offer: %s.", want, strings.Join(available, ", ")))
+		return
+	}
+
+	replyMarkdown(t.bot, chatID, "*"+escapeMarkdown(fmt.Sprintf("Missions worth %d V-Bucks", want))+"*\n\n"+formatMissionList(formatOptions{}, matching))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultAreaAliases maps common shorthand to canonical area names
+var defaultAreaAliases = map[string]string{
+	"stonewood":  "Stonewood",
+	"sw":         "Stonewood",
+	"plank":      "Plankerton",
+	"plankerton": "Plankerton",
+	"pl":         "Plankerton",
+	"canny":      "Canny Valley",
+	"cv":         "Canny Valley",
+	"twine":      "Twine Peaks",
+	"tp":         "Twine Peaks",
+}
+
+// areaAliases is the alias map in use, keyed by lowercase alias
+var areaAliases = defaultAreaAliases
+
+// loadAreaAliases merges AREA_ALIASES ("tp=Twine Peaks,cv=Canny Valley") over
+// the default aliases
+func loadAreaAliases() error {
+	aliases := map[string]string{}
+	for alias, area := range defaultAreaAliases {
+		aliases[alias] = area
+	}
+
+	if v := os.Getenv("AREA_ALIASES"); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+				return fmt.Errorf("invalid AREA_ALIASES entry %q, expected alias=Area Name", pair)
+			}
+			aliases[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	areaAliases = aliases
+	return nil
+}
+
+// resolveArea turns an alias or differently-cased area name into its
+// canonical form, returning the name unchanged when it isn't known
+func resolveArea(name string) string {
+	name = strings.TrimSpace(name)
+
+	configMu.RLock()
+	aliases := areaAliases
+	configMu.RUnlock()
+
+	if area, ok := aliases[strings.ToLower(name)]; ok {
+		return area
+	}
+	for _, area := range aliases {
+		if strings.EqualFold(area, name) {
+			return area
+		}
+	}
+
+	return name
+}
+
+// loggedAreas remembers the raw area names already reported as normalized,
+// so each inconsistency is logged once rather than on every scrape
+var loggedAreas sync.Map
+
+// normalizeArea is how parsers clean up the area a source reported: runs of
+// whitespace are collapsed and known names get their canonical form, so
+// "twine  peaks" and "Twine Peaks" group, filter and record as one area
+// Changes beyond trimming are logged to help spot source inconsistencies
+func normalizeArea(raw string) string {
+	trimmed := strings.Join(strings.Fields(raw), " ")
+	area := resolveArea(trimmed)
+
+	if area != strings.TrimSpace(raw) {
+		if _, seen := loggedAreas.LoadOrStore(raw, true); !seen {
+			log.Printf("Normalized area %q from the source to %q", raw, area)
+		}
+	}
+	return area
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveArea(t *testing.T) {
+	tests := map[string]string{
+		"twine":        "Twine Peaks",
+		"TP":           "Twine Peaks",
+		"canny":        "Canny Valley",
+		" cv ":         "Canny Valley",
+		"plank":        "Plankerton",
+		"sw":           "Stonewood",
+		"twine peaks":  "Twine Peaks",
+		"CANNY VALLEY": "Canny Valley",
+		"Ventures":     "Ventures",
+	}
+	for name, want := range tests {
+		if got := resolveArea(name); got != want {
+			t.Errorf("resolveArea(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLoadAreaAliasesOverrides(t *testing.T) {
+	saved := areaAliases
+	t.Cleanup(func() { areaAliases = saved })
+
+	t.Setenv("AREA_ALIASES", "peaks=Twine Peaks, vent = Ventures")
+	if err := loadAreaAliases(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"peaks": "Twine Peaks", "vent": "Ventures", "tp": "Twine Peaks"} {
+		if got := resolveArea(name); got != want {
+			t.Errorf("resolveArea(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	t.Setenv("AREA_ALIASES", "broken")
+	if err := loadAreaAliases(); err == nil {
+		t.Error("loadAreaAliases accepted AREA_ALIASES=broken")
+	}
+}
+
+func TestNormalizeArea(t *testing.T) {
+	tests := map[string]string{
+		"Twine Peaks":      "Twine Peaks",
+		"twine peaks":      "Twine Peaks",
+		"TWINE  PEAKS":     "Twine Peaks",
+		" Canny\tValley ":  "Canny Valley",
+		"plankerton":       "Plankerton",
+		"tp":               "Twine Peaks",
+		"stoneWOOD":        "Stonewood",
+		"  Ventures  Zone": "Ventures Zone",
+	}
+	for raw, want := range tests {
+		if got := normalizeArea(raw); got != want {
+			t.Errorf("normalizeArea(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestParsersNormalizeAreas(t *testing.T) {
+	text, ok := parseMissionText("80 140Ride the Lightning in twine PEAKS")
+	if !ok {
+		t.Fatal("parseMissionText rejected the mission")
+	}
+	if text.Area != "Twine Peaks" {
+		t.Errorf("parseMissionText area = %q, want Twine Peaks", text.Area)
+	}
+
+	page := `<ul><li class="m"><span class="a">CANNY  valley</span><span class="v">50</span></li></ul>`
+	sel := Selectors{Mission: "li.m", Area: ".a", Amount: ".v"}
+	selected, err := parseSelectorHTML(sel, []byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 1 || selected[0].Area != "Canny Valley" {
+		t.Errorf("parseSelectorHTML = %+v, want one mission in Canny Valley", selected)
+	}
+
+	payload := `[{"Area": "plankerton", "Amount": "50"}, {"Area": "sw", "Amount": "40"}]`
+	fromJSON, err := parseJSONMissions([]byte(payload), Source{Name: "api", Kind: SourceKindJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fromJSON) != 2 || fromJSON[0].Area != "Plankerton" || fromJSON[1].Area != "Stonewood" {
+		t.Errorf("parseJSONMissions = %+v, want Plankerton and Stonewood", fromJSON)
+	}
+}
+
+func TestLoadHistoryNormalizesAreas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	data := `{"2024-03-01": [{"Area": "twine peaks", "Amount": "80"}, {"Area": "Twine Peaks", "Amount": "40"}]}`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := loadHistory(path, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missions, _ := h.missionsOn("2024-03-01")
+	if len(missions) != 2 || missions[0].Area != "Twine Peaks" || missions[1].Area != "Twine Peaks" {
+		t.Errorf("loaded %+v, want both missions in Twine Peaks", missions)
+	}
+}
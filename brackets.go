@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// powerBracket is a range of power levels /brackets groups missions into
+type powerBracket struct {
+	label    string
+	min, max int // max of 0 means no upper bound
+}
+
+// powerBrackets roughly follow the progression through the four zones
+var powerBrackets = []powerBracket{
+	{"1-20", 1, 20},
+	{"21-40", 21, 40},
+	{"41-60", 41, 60},
+	{"61-82", 61, 82},
+	{"83+", 83, 0},
+}
+
+// bracketFor returns the index of the bracket a mission falls in, using the
+// top of its power level range, or -1 when it has no usable power level
+func bracketFor(m VBucksMission) int {
+	_, level, ok := powerRange(m.PowerLevel)
+	if !ok {
+		return -1
+	}
+	for i, b := range powerBrackets {
+		if level >= b.min && (b.max == 0 || level <= b.max) {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatBrackets counts missions and V-Bucks per power level bracket for
+// /brackets, leaving out empty brackets
+// The table is sent as a MarkdownV2 code block so the columns line up
+func formatBrackets(vbucksMissions []VBucksMission) string {
+	if len(vbucksMissions) == 0 {
+		return escapeMarkdown("No V-Bucks missions found today.")
+	}
+
+	counts := make([]int, len(powerBrackets)+1)
+	totals := make([]int, len(powerBrackets)+1)
+	for _, mission := range vbucksMissions {
+		i := bracketFor(mission)
+		if i < 0 {
+			// Missions without a power level go in the last row
+			i = len(powerBrackets)
+		}
+		counts[i]++
+		totals[i] += totalVBucks([]VBucksMission{mission})
+	}
+
+	var result strings.Builder
+	result.WriteString("*Missions by power level*\n```\n")
+	result.WriteString(fmt.Sprintf("%-8s %8s %8s\n", "PL", "Missions", "V-Bucks"))
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		label := "unknown"
+		if i < len(powerBrackets) {
+			label = powerBrackets[i].label
+		}
+		result.WriteString(fmt.Sprintf("%-8s %8d %8d\n", label, count, totals[i]))
+	}
+	result.WriteString("```")
+
+	return result.String()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// testMissions is a small mission list for round-trip tests
+var testMissions = []VBucksMission{
+	{Area: "Twine Peaks", PowerLevel: "140", Amount: "80", MissionType: "Ride the Lightning"},
+	{Area: "Canny Valley", PowerLevel: "76-82", Amount: "50", MissionType: "Fight the Storm"},
+}
+
+// withCacheCompress sets CACHE_COMPRESS for the test
+func withCacheCompress(t *testing.T, compress bool) {
+	t.Helper()
+	saved := cacheCompress
+	cacheCompress = compress
+	t.Cleanup(func() { cacheCompress = saved })
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		inTempDir(t)
+		withCacheCompress(t, compress)
+
+		saveToCache(testMissions, nil, nil)
+
+		data, err := ioutil.ReadFile(cacheFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isGzip(data) != compress {
+			t.Errorf("CACHE_COMPRESS=%v wrote a gzipped file: %v", compress, isGzip(data))
+		}
+
+		cached, ok := readCacheFile()
+		if !ok {
+			t.Fatalf("CACHE_COMPRESS=%v: cache file couldn't be read back", compress)
+		}
+		if !reflect.DeepEqual(cached.VBucksMissions, testMissions) {
+			t.Errorf("CACHE_COMPRESS=%v: read back %+v, want %+v", compress, cached.VBucksMissions, testMissions)
+		}
+	}
+}
+
+func TestCacheReadsEitherFormat(t *testing.T) {
+	// Files written before the flag was flipped must still load
+	for _, writeCompressed := range []bool{false, true} {
+		inTempDir(t)
+		withCacheCompress(t, writeCompressed)
+		saveToCache(testMissions, nil, nil)
+
+		cacheCompress = !writeCompressed
+		cached, ok := readCacheFile()
+		if !ok || !reflect.DeepEqual(cached.VBucksMissions, testMissions) {
+			t.Errorf("cache written with CACHE_COMPRESS=%v didn't load after flipping it", writeCompressed)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheWriteRetries is how many times a failed cache write is retried
+	defaultCacheWriteRetries = 2
+	// defaultCacheWriteDelay is the wait between cache write attempts
+	defaultCacheWriteDelay = 500 * time.Millisecond
+)
+
+var (
+	// cacheWriteRetries and cacheWriteDelay are set with CACHE_WRITE_RETRIES
+	// and CACHE_WRITE_DELAY
+	cacheWriteRetries = defaultCacheWriteRetries
+	cacheWriteDelay   = defaultCacheWriteDelay
+)
+
+// writeCacheFile writes the cache file; tests swap it to make writes fail
+var writeCacheFile = ioutil.WriteFile
+
+// memoryCache holds the last scrape when it couldn't be written to the cache
+// file, so the process keeps serving it until a write succeeds
+var memoryCache = &memoryCacheStore{}
+
+// memoryCacheStore is the in-memory stand-in for the cache file
+type memoryCacheStore struct {
+	mu   sync.Mutex
+	data *CacheData
+}
+
+// get returns the cache kept in memory, if any
+func (m *memoryCacheStore) get() (CacheData, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.data == nil {
+		return CacheData{}, false
+	}
+	return *m.data, true
+}
+
+// set keeps data in memory; nil goes back to the cache file
+func (m *memoryCacheStore) set(data *CacheData) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = data
+}
+
+// loadCacheWriteRetries reads CACHE_WRITE_RETRIES and CACHE_WRITE_DELAY
+func loadCacheWriteRetries() error {
+	delay, err := durationSetting("CACHE_WRITE_DELAY", defaultCacheWriteDelay)
+	if err != nil {
+		return err
+	}
+
+	retries := defaultCacheWriteRetries
+	if v := os.Getenv("CACHE_WRITE_RETRIES"); v != "" {
+		retries, err = strconv.Atoi(v)
+		if err != nil || retries < 0 {
+			return fmt.Errorf("invalid CACHE_WRITE_RETRIES %q: must be zero or more", v)
+		}
+	}
+
+	cacheWriteRetries = retries
+	cacheWriteDelay = delay
+	return nil
+}
+
+// writeCache writes the encoded cache to the cache file, retrying a few
+// times; if every attempt fails the cache is kept in memory instead
+func writeCache(cacheData CacheData, encoded []byte) {
+	var err error
+	for attempt := 0; attempt <= cacheWriteRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(cacheWriteDelay)
+		}
+		if err = writeCacheFile(cacheFile, encoded, 0644); err == nil {
+			if _, inMemory := memoryCache.get(); inMemory {
+				log.Print("Cache file writable again, no longer keeping the cache in memory")
+			}
+			memoryCache.set(nil)
+			return
+		}
+		log.Printf("Error writing cache file (attempt %d of %d): %v", attempt+1, cacheWriteRetries+1, err)
+	}
+
+	log.Printf("Giving up on the cache file, keeping the cache in memory until a write succeeds")
+	memoryCache.set(&cacheData)
+}
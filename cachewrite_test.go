@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// failCacheWrites makes the first n cache file writes fail and returns a
+// pointer to the number of attempts made
+func failCacheWrites(t *testing.T, n int) *int {
+	t.Helper()
+	savedWrite, savedRetries, savedDelay := writeCacheFile, cacheWriteRetries, cacheWriteDelay
+	cacheWriteRetries, cacheWriteDelay = 2, time.Millisecond
+	t.Cleanup(func() {
+		writeCacheFile, cacheWriteRetries, cacheWriteDelay = savedWrite, savedRetries, savedDelay
+		memoryCache.set(nil)
+	})
+
+	attempts := 0
+	writeCacheFile = func(name string, data []byte, perm os.FileMode) error {
+		attempts++
+		if attempts <= n {
+			return errors.New("no space left on device")
+		}
+		return ioutil.WriteFile(name, data, perm)
+	}
+	return &attempts
+}
+
+func TestWriteCacheRetries(t *testing.T) {
+	inTempDir(t)
+	attempts := failCacheWrites(t, 2)
+
+	data := CacheData{Timestamp: time.Now().UTC(), VBucksMissions: testMissions}
+	writeCache(data, []byte(`{"ok": true}`))
+
+	if *attempts != 3 {
+		t.Errorf("made %d write attempts, want 3", *attempts)
+	}
+	if written, err := ioutil.ReadFile(cacheFile); err != nil || string(written) != `{"ok": true}` {
+		t.Errorf("cache file = %q, %v after the third attempt succeeded", written, err)
+	}
+	if _, inMemory := memoryCache.get(); inMemory {
+		t.Error("cache kept in memory although the write succeeded")
+	}
+}
+
+func TestWriteCacheFallsBackToMemory(t *testing.T) {
+	inTempDir(t)
+	attempts := failCacheWrites(t, 3)
+
+	data := CacheData{Timestamp: time.Now().UTC(), VBucksMissions: testMissions}
+	writeCache(data, []byte(`{}`))
+
+	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
+		t.Errorf("cache file exists although every write failed: %v", err)
+	}
+	cached, ok := memoryCache.get()
+	if !ok || !reflect.DeepEqual(cached.VBucksMissions, testMissions) {
+		t.Fatalf("memory cache = %+v, %v, want the missions", cached, ok)
+	}
+	if loaded, _ := loadFromCache(); !reflect.DeepEqual(loaded.VBucksMissions, testMissions) {
+		t.Errorf("loadFromCache = %+v, want the in-memory missions", loaded.VBucksMissions)
+	}
+
+	// The next write that succeeds goes back to the file
+	writeCache(data, []byte(`{}`))
+	if *attempts != 4 {
+		t.Errorf("made %d write attempts, want 4", *attempts)
+	}
+	if _, inMemory := memoryCache.get(); inMemory {
+		t.Error("cache still kept in memory after a successful write")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// calendarWeeks is how many weeks /calendar shows, the current one included
+const calendarWeeks = 5
+
+// Calendar squares, from no data to the busiest days
+const (
+	calendarNoData = "⬜"
+	calendarNone   = "⬛"
+)
+
+// calendarShades shade days with V-Bucks, from the lowest third of the
+// period's highest total to the top third
+var calendarShades = []string{"🟨", "🟧", "🟥"}
+
+// calendarSquare picks the square for a day's total
+func calendarSquare(p chartPoint, max int) string {
+	switch {
+	case !p.OK:
+		return calendarNoData
+	case p.Total == 0 || max == 0:
+		return calendarNone
+	}
+
+	bucket := p.Total * len(calendarShades) / (max + 1)
+	return calendarShades[bucket]
+}
+
+// formatCalendar handles /calendar: one row of squares per week, Monday
+// first, shaded by the V-Bucks on offer each day
+func formatCalendar(h *historyStore, now time.Time) string {
+	today := now.UTC()
+	// Start on the Monday calendarWeeks-1 weeks back
+	offset := (int(today.Weekday()) + 6) % 7
+	days := offset + 1 + (calendarWeeks-1)*7
+	points := dailyTotals(h, days, today)
+
+	max, recorded := 0, 0
+	for _, p := range points {
+		if p.OK {
+			recorded++
+			if p.Total > max {
+				max = p.Total
+			}
+		}
+	}
+	if recorded == 0 {
+		return "No mission history yet. Check back after a day of scraping."
+	}
+
+	var b strings.Builder
+	b.WriteString("V-Bucks on offer each day, Monday to Sunday:\n")
+	for week := 0; week*7 < len(points); week++ {
+		end := week*7 + 7
+		if end > len(points) {
+			end = len(points)
+		}
+		fmt.Fprintf(&b, "\n%s ", points[week*7].Date[5:])
+		for _, p := range points[week*7 : end] {
+			b.WriteString(calendarSquare(p, max))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n\n%s no data  %s none", calendarNoData, calendarNone)
+	for i, shade := range calendarShades {
+		// The highest total that still falls in this shade's bucket
+		upTo := ((i+1)*(max+1) - 1) / len(calendarShades)
+		fmt.Fprintf(&b, "  %s up to %d", shade, upTo)
+	}
+	if days > h.retention {
+		fmt.Fprintf(&b, "\nHistory is only kept for %s, so older days have no data.", plural(h.retention, "day"))
+	}
+
+	return b.String()
+}
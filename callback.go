@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// callbackAttempts is how many times a scrape callback is tried in total
+	callbackAttempts = 3
+	// callbackRetryDelay is the wait before the first retry; it doubles each time
+	callbackRetryDelay = 2 * time.Second
+	// callbackTimeout bounds each callback request
+	callbackTimeout = 10 * time.Second
+	// signatureHeader carries the HMAC-SHA256 of the body, hex encoded
+	signatureHeader = "X-Signature-256"
+)
+
+// scrapeCallback POSTs the outcome of every scrape to CALLBACK_URL
+// A nil callback is a no-op, so callers don't need to check whether it's enabled
+type scrapeCallback struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// callback is set when CALLBACK_URL is
+var callback *scrapeCallback
+
+// callbackPayload is the JSON body of a scrape callback
+type callbackPayload struct {
+	Success   bool
+	Error     string `json:",omitempty"`
+	Missions  int
+	Total     int
+	Timestamp time.Time
+}
+
+// startCallback reads CALLBACK_URL and CALLBACK_SECRET
+func startCallback() {
+	url := os.Getenv("CALLBACK_URL")
+	if url == "" {
+		return
+	}
+
+	secret := os.Getenv("CALLBACK_SECRET")
+	if secret == "" {
+		log.Print("CALLBACK_SECRET isn't set, scrape callbacks won't be signed")
+	}
+	callback = &scrapeCallback{url: url, secret: secret, client: &http.Client{Timeout: callbackTimeout}}
+
+	log.Printf("Posting scrape results to %s", url)
+}
+
+// sign returns the signature header value for body
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notify posts the outcome of a scrape in the background, retrying a few
+// times; failures are only logged since the callback is best effort
+func (c *scrapeCallback) notify(missions []VBucksMission, scrapeErr error) {
+	if c == nil {
+		return
+	}
+
+	payload := callbackPayload{
+		Success:   scrapeErr == nil,
+		Missions:  len(missions),
+		Total:     totalVBucks(missions),
+		Timestamp: time.Now().UTC(),
+	}
+	if scrapeErr != nil {
+		payload.Error = scrapeErr.Error()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding scrape callback: %v", err)
+		return
+	}
+
+	go func() {
+		delay := callbackRetryDelay
+		for attempt := 1; ; attempt++ {
+			err := c.post(body)
+			if err == nil {
+				return
+			}
+			if attempt == callbackAttempts {
+				log.Printf("Error posting scrape callback after %d attempts: %v", attempt, err)
+				return
+			}
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}()
+}
+
+// post sends one callback request
+func (c *scrapeCallback) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		req.Header.Set(signatureHeader, sign(c.secret, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback answered HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// callbackRequest is a callback as the receiving server saw it
+type callbackRequest struct {
+	body      []byte
+	signature string
+}
+
+// callbackServer records the callbacks it gets, answering each with the next
+// status in statuses and 200 once they run out
+func callbackServer(t *testing.T, statuses ...int) (*httptest.Server, <-chan callbackRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	received := make(chan callbackRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- callbackRequest{body: body, signature: r.Header.Get(signatureHeader)}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[0])
+			statuses = statuses[1:]
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+// nextCallback waits for the server to get a callback
+func nextCallback(t *testing.T, received <-chan callbackRequest, wait time.Duration) callbackRequest {
+	t.Helper()
+	select {
+	case req := <-received:
+		return req
+	case <-time.After(wait):
+		t.Fatal("no callback received")
+		return callbackRequest{}
+	}
+}
+
+// verifySignature checks a signature header the way a receiver would
+func verifySignature(secret string, body []byte, header string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(header), []byte(want))
+}
+
+func TestCallbackPayloadAndSignature(t *testing.T) {
+	srv, received := callbackServer(t)
+	c := &scrapeCallback{url: srv.URL, secret: "s3cret", client: srv.Client()}
+
+	c.notify(testMissions, nil)
+	req := nextCallback(t, received, 2*time.Second)
+
+	if !verifySignature("s3cret", req.body, req.signature) {
+		t.Errorf("signature %q doesn't verify for body %s", req.signature, req.body)
+	}
+	if verifySignature("other", req.body, req.signature) {
+		t.Error("signature verifies with the wrong secret")
+	}
+
+	var payload callbackPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if !payload.Success || payload.Missions != 2 || payload.Total != 130 || payload.Error != "" || payload.Timestamp.IsZero() {
+		t.Errorf("payload = %+v, want a success with 2 missions worth 130", payload)
+	}
+}
+
+func TestCallbackReportsFailure(t *testing.T) {
+	srv, received := callbackServer(t)
+	c := &scrapeCallback{url: srv.URL, client: srv.Client()}
+
+	c.notify(nil, errors.New("all sources failed"))
+	req := nextCallback(t, received, 2*time.Second)
+
+	if req.signature != "" {
+		t.Errorf("unsigned callback sent signature %q", req.signature)
+	}
+	var payload callbackPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Success || !strings.Contains(payload.Error, "all sources failed") {
+		t.Errorf("payload = %+v, want the failure", payload)
+	}
+}
+
+func TestCallbackRetries(t *testing.T) {
+	srv, received := callbackServer(t, http.StatusInternalServerError)
+	c := &scrapeCallback{url: srv.URL, secret: "s3cret", client: srv.Client()}
+
+	c.notify(testMissions, nil)
+	first := nextCallback(t, received, 2*time.Second)
+	retry := nextCallback(t, received, callbackRetryDelay+2*time.Second)
+
+	if string(first.body) != string(retry.body) || first.signature != retry.signature {
+		t.Error("the retry didn't resend the same signed body")
+	}
+}
+
+func TestNilCallbackIsNoop(t *testing.T) {
+	var c *scrapeCallback
+	c.notify(testMissions, nil)
+}
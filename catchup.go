@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// missedDays returns the days after lastNotified whose push time has passed,
+// within the history retention window, that the chat wasn't notified about
+func missedDays(h *historyStore, lastNotified string, now time.Time) []string {
+	// Today only counts once its push should have gone out
+	today := now.UTC().Format(dateLayout)
+	if pushTime := nextReset(now).AddDate(0, 0, -1).Add(notifyDelay); now.Before(pushTime) {
+		today = now.UTC().AddDate(0, 0, -1).Format(dateLayout)
+	}
+
+	var missed []string
+	for day := now.UTC().AddDate(0, 0, -(h.retention - 1)); day.Format(dateLayout) <= today; day = day.AddDate(0, 0, 1) {
+		if date := day.Format(dateLayout); date > lastNotified {
+			missed = append(missed, date)
+		}
+	}
+	return missed
+}
+
+// formatCatchUp summarizes the missed days for a chat, honoring its filters
+func formatCatchUp(h *historyStore, prefs ChatPreferences, dates []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The bot was down, so here's what you missed over %s:\n", plural(len(dates), "day"))
+
+	grand := 0
+	for _, date := range dates {
+		missions, ok := h.missionsOn(date)
+		if !ok {
+			// Nothing was scraped that day either
+			fmt.Fprintf(&b, "\n%s: no data", date)
+			continue
+		}
+		missions = filterForChat(prefs, missions)
+		total, estimated := sumVBucks(missions)
+		grand += total
+		fmt.Fprintf(&b, "\n%s: %s, %d V-Bucks%s", date, plural(len(missions), "mission"), total, estimateNote(estimated))
+	}
+	fmt.Fprintf(&b, "\n\nTotal: %d V-Bucks. /vbucks for today's list.", grand)
+
+	return b.String()
+}
+
+// catchUp sends every subscriber that missed daily notifications while the
+// bot was down a single summary of the days it missed
+// Only runs with CATCH_UP=1 so a restart doesn't surprise anyone with messages
+func catchUp(t *tenant) {
+	if os.Getenv("CATCH_UP") != "1" {
+		return
+	}
+
+	// Scrape first so today is in the history
+	getMissions()
+
+	now := time.Now()
+	sent := 0
+	for _, chatID := range t.prefs.subscribers() {
+		prefs := t.prefs.get(chatID)
+		// Chats never notified have nothing to catch up on
+		if prefs.LastNotified == "" {
+			continue
+		}
+
+		missed := missedDays(history, prefs.LastNotified, now)
+		if prefs.WeekendsOnly {
+			missed = weekendDates(missed)
+		}
+		if len(missed) == 0 {
+			continue
+		}
+
+		if err := send(t.bot, tgbotapi.NewMessage(chatID, formatCatchUp(history, prefs, missed))); err != nil {
+			if isBlocked(err) {
+				unsubscribeBlocked(t.prefs, chatID)
+			}
+			continue
+		}
+		sent++
+
+		markNotified(t.prefs, chatID, missed[len(missed)-1])
+	}
+
+	if sent > 0 {
+		log.Printf("[%s] Sent catch-up summaries to %d subscribers", t.bot.Self.UserName, sent)
+	}
+}
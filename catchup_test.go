@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withHistory swaps the shared mission history for the test
+func withHistory(t *testing.T, h *historyStore) {
+	t.Helper()
+	saved := history
+	history = h
+	t.Cleanup(func() { history = saved })
+}
+
+func TestCatchUpWeekendsOnly(t *testing.T) {
+	inTempDir(t)
+	withFixture(t, testMissions)
+	t.Setenv("CATCH_UP", "1")
+
+	now := time.Now()
+	h := &historyStore{path: historyFile, retention: 30, days: map[string][]VBucksMission{}}
+	for i := 0; i < 10; i++ {
+		h.days[now.UTC().AddDate(0, 0, -i).Format(dateLayout)] = testMissions
+	}
+	withHistory(t, h)
+
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	lastNotified := now.UTC().AddDate(0, 0, -8).Format(dateLayout)
+	for chatID, weekends := range map[int64]bool{1: false, 2: true} {
+		if err := tn.prefs.update(chatID, func(p *ChatPreferences) {
+			p.Subscribed, p.WeekendsOnly, p.LastNotified = true, weekends, lastNotified
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	catchUp(tn)
+
+	texts := map[string]string{}
+	fake.mu.Lock()
+	for _, req := range fake.requests {
+		texts[req.Form.Get("chat_id")] = req.Form.Get("text")
+	}
+	fake.mu.Unlock()
+
+	missed := missedDays(h, lastNotified, now)
+	weekends := weekendDates(missed)
+	for _, date := range missed {
+		if !strings.Contains(texts["1"], date+":") {
+			t.Errorf("catch-up for chat 1 leaves out %s", date)
+		}
+		isWeekend := len(weekendDates([]string{date})) == 1
+		if strings.Contains(texts["2"], date+":") != isWeekend {
+			t.Errorf("catch-up for the weekends-only chat lists %s: %v, want %v", date, !isWeekend, isWeekend)
+		}
+	}
+
+	// Eight missed days always include a weekend
+	if got, want := tn.prefs.get(2).LastNotified, weekends[len(weekends)-1]; got != want {
+		t.Errorf("weekends-only chat LastNotified = %s, want the last weekend day %s", got, want)
+	}
+	if got, want := tn.prefs.get(1).LastNotified, missed[len(missed)-1]; got != want {
+		t.Errorf("chat 1 LastNotified = %s, want %s", got, want)
+	}
+}
+
+func TestMissedDays(t *testing.T) {
+	h := &historyStore{retention: 7}
+	tests := []struct {
+		name         string
+		lastNotified string
+		now          time.Time
+		want         []string
+	}{
+		{
+			name:         "after the push",
+			lastNotified: "2024-03-03",
+			now:          time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+			want:         []string{"2024-03-04", "2024-03-05", "2024-03-06"},
+		},
+		{
+			// The push goes out at 00:15 UTC, five minutes after the reset
+			name:         "before today's push",
+			lastNotified: "2024-03-03",
+			now:          time.Date(2024, 3, 6, 0, 12, 0, 0, time.UTC),
+			want:         []string{"2024-03-04", "2024-03-05"},
+		},
+		{
+			name:         "up to date",
+			lastNotified: "2024-03-06",
+			now:          time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "past the retention window",
+			lastNotified: "2024-01-01",
+			now:          time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+			want:         []string{"2024-02-29", "2024-03-01", "2024-03-02", "2024-03-03", "2024-03-04", "2024-03-05", "2024-03-06"},
+		},
+	}
+	for _, tt := range tests {
+		if got := missedDays(h, tt.lastNotified, tt.now); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: missedDays = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCatchUp(t *testing.T) {
+	h := &historyStore{retention: 7, days: map[string][]VBucksMission{
+		"2024-03-04": testMissions,
+		"2024-03-06": testMissions[:1],
+	}}
+
+	got := formatCatchUp(h, ChatPreferences{}, []string{"2024-03-04", "2024-03-05", "2024-03-06"})
+	want := "The bot was down, so here's what you missed over 3 days:\n" +
+		"\n2024-03-04: 2 missions, 130 V-Bucks" +
+		"\n2024-03-05: no data" +
+		"\n2024-03-06: 1 mission, 80 V-Bucks" +
+		"\n\nTotal: 210 V-Bucks. /vbucks for today's list."
+	if got != want {
+		t.Errorf("formatCatchUp =\n%s\nwant\n%s", got, want)
+	}
+
+	// The chat's filters apply to each day
+	got = formatCatchUp(h, ChatPreferences{Area: "Canny Valley"}, []string{"2024-03-04"})
+	if !strings.Contains(got, "2024-03-04: 1 mission, 50 V-Bucks") {
+		t.Errorf("formatCatchUp with an area filter =\n%s", got)
+	}
+}
+
+func TestCatchUpSkipsDaysWithNothingDue(t *testing.T) {
+	inTempDir(t)
+	withFixture(t, testMissions)
+	t.Setenv("CATCH_UP", "1")
+
+	now := time.Now()
+	yesterday := now.UTC().AddDate(0, 0, -1).Format(dateLayout)
+	h := &historyStore{path: historyFile, retention: 30, days: map[string][]VBucksMission{
+		yesterday:                    testMissions,
+		now.UTC().Format(dateLayout): testMissions,
+	}}
+	withHistory(t, h)
+
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	seen := map[string]string{}
+	for _, m := range testMissions {
+		seen[m.key()] = yesterday
+	}
+	// Chat 1 only wants days above its average, chat 2 only new missions
+	updates := map[int64]func(p *ChatPreferences){
+		1: func(p *ChatPreferences) { p.NotifyMode, p.AverageTotal, p.AverageDays = notifyDigest, 10000, 7 },
+		2: func(p *ChatPreferences) { p.OnlyNew, p.Seen = true, seen },
+	}
+	for chatID, update := range updates {
+		if err := tn.prefs.update(chatID, func(p *ChatPreferences) {
+			p.Subscribed, p.LastNotified = true, yesterday
+			update(p)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The bot is running: neither chat has anything due today
+	broadcastMissions(tn, testMissions, Freshness{UpdatedAt: now})
+	// Then it restarts
+	catchUp(tn)
+
+	if sent := fake.sent(); len(sent) != 0 {
+		t.Errorf("sent %q, want nothing for days the bot was up", sent)
+	}
+	for chatID := range updates {
+		if got, want := tn.prefs.get(chatID).LastNotified, now.UTC().Format(dateLayout); got != want {
+			t.Errorf("chat %d LastNotified = %s, want %s", chatID, got, want)
+		}
+	}
+}
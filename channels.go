@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fallbackChannelKey is the AREA_CHANNELS entry for areas without a channel
+const fallbackChannelKey = "*"
+
+// areaChannels maps area names, as written in AREA_CHANNELS, to the chat the
+// area's missions are posted to every day
+var areaChannels map[string]int64
+
+// loadAreaChannels reads AREA_CHANNELS ("Stonewood=-100123,Twine Peaks=-100456,*=-100789")
+func loadAreaChannels() error {
+	channels := map[string]int64{}
+
+	if v := os.Getenv("AREA_CHANNELS"); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+				return fmt.Errorf("invalid AREA_CHANNELS entry %q, expected Area Name=chat ID", pair)
+			}
+			chatID, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid AREA_CHANNELS entry %q: chat ID must be a number", pair)
+			}
+			channels[strings.TrimSpace(parts[0])] = chatID
+		}
+	}
+
+	areaChannels = channels
+	return nil
+}
+
+// splitByChannel groups the missions by the chat their area is mapped to,
+// keeping their order; areas with no mapping go to the fallback channel, or
+// nowhere when there is none
+func splitByChannel(vbucksMissions []VBucksMission, channels map[string]int64) map[int64][]VBucksMission {
+	byArea := map[string]int64{}
+	for name, chatID := range channels {
+		if name != fallbackChannelKey {
+			byArea[strings.ToLower(resolveArea(name))] = chatID
+		}
+	}
+	fallback, hasFallback := channels[fallbackChannelKey]
+
+	groups := map[int64][]VBucksMission{}
+	for _, mission := range vbucksMissions {
+		chatID, ok := byArea[strings.ToLower(mission.Area)]
+		if !ok {
+			if !hasFallback {
+				continue
+			}
+			chatID = fallback
+		}
+		groups[chatID] = append(groups[chatID], mission)
+	}
+	return groups
+}
+
+// postAreaChannels sends each channel in AREA_CHANNELS the day's missions in
+// the areas mapped to it; channels with no missions that day get nothing
+func postAreaChannels(t *tenant, vbucksMissions []VBucksMission, freshness Freshness) {
+	configMu.RLock()
+	channels := areaChannels
+	configMu.RUnlock()
+	if len(channels) == 0 {
+		return
+	}
+
+	groups := splitByChannel(vbucksMissions, channels)
+	chatIDs := make([]int64, 0, len(groups))
+	for chatID := range groups {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sort.Slice(chatIDs, func(i, j int) bool { return chatIDs[i] < chatIDs[j] })
+
+	for _, chatID := range chatIDs {
+		text := staleWarning(freshness) + formatMissionList(formatOptions{}, groups[chatID])
+		if err := sendNotification(t.bot, chatID, text); err != nil {
+			log.Printf("Error posting missions to channel %s: %v", chatLabels.name(chatID), err)
+		}
+	}
+	log.Printf("[%s] Missions posted to %d area channels", t.bot.Self.UserName, len(chatIDs))
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// defaultChartDays is how many days /chart plots without an argument
+	defaultChartDays = 14
+	// Chart size and the margin around the plot area, in pixels
+	chartWidth  = 720
+	chartHeight = 360
+	chartMargin = 30
+)
+
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartAxis       = color.RGBA{0x44, 0x44, 0x44, 0xff}
+	chartGrid       = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	chartLine       = color.RGBA{0x1e, 0x88, 0xe5, 0xff}
+	chartMissing    = color.RGBA{0xe5, 0x39, 0x35, 0xff}
+)
+
+// chartPoint is one day on the chart; days without history have OK unset
+type chartPoint struct {
+	Date  string
+	Total int
+	OK    bool
+}
+
+// chartCache keeps the last rendered chart, since the same range is usually
+// asked for many times a day
+var chartCache struct {
+	mu  sync.Mutex
+	key string
+	png []byte
+}
+
+// dailyTotals returns the V-Bucks offered on each of the last days days
+func dailyTotals(h *historyStore, days int, now time.Time) []chartPoint {
+	points := make([]chartPoint, 0, days)
+	for day := now.UTC().AddDate(0, 0, -(days - 1)); len(points) < days; day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		missions, ok := h.missionsOn(date)
+		total, _ := sumVBucks(missions)
+		points = append(points, chartPoint{Date: date, Total: total, OK: ok})
+	}
+	return points
+}
+
+// chartKey identifies a series so an unchanged one isn't rendered again
+func chartKey(points []chartPoint) string {
+	sum := sha256.New()
+	for _, p := range points {
+		fmt.Fprintf(sum, "%s=%d,%t;", p.Date, p.Total, p.OK)
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// renderChart draws the totals as a line chart PNG
+// Lines only join consecutive days with data; days without data get a red
+// mark on the baseline so gaps are visible
+func renderChart(points []chartPoint) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	fillRect(img, 0, 0, chartWidth, chartHeight, chartBackground)
+
+	left, right := chartMargin, chartWidth-chartMargin
+	top, bottom := chartMargin, chartHeight-chartMargin
+
+	max := 0
+	for _, p := range points {
+		if p.OK && p.Total > max {
+			max = p.Total
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	// Grid lines at each quarter of the highest total
+	for i := 1; i <= 4; i++ {
+		y := bottom - (bottom-top)*i/4
+		drawLine(img, left, y, right, y, chartGrid)
+	}
+	drawLine(img, left, top, left, bottom, chartAxis)
+	drawLine(img, left, bottom, right, bottom, chartAxis)
+
+	x := func(i int) int {
+		if len(points) == 1 {
+			return (left + right) / 2
+		}
+		return left + (right-left)*i/(len(points)-1)
+	}
+	y := func(total int) int {
+		return bottom - (bottom-top)*total/max
+	}
+
+	for i, p := range points {
+		if !p.OK {
+			fillRect(img, x(i)-2, bottom-2, x(i)+3, bottom+3, chartMissing)
+			continue
+		}
+		if i > 0 && points[i-1].OK {
+			drawLine(img, x(i-1), y(points[i-1].Total), x(i), y(p.Total), chartLine)
+		}
+		fillRect(img, x(i)-3, y(p.Total)-3, x(i)+4, y(p.Total)+4, chartLine)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fillRect paints the rectangle from (x0, y0) up to but not including (x1, y1)
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+// drawLine draws a two pixel wide line with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	for err := dx + dy; ; {
+		img.Set(x0, y0, c)
+		img.Set(x0+1, y0, c)
+		img.Set(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x0 += sx
+		} else if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// chartCaption summarizes the plotted series
+func chartCaption(points []chartPoint) string {
+	var days, total, min, max int
+	for _, p := range points {
+		if !p.OK {
+			continue
+		}
+		if days == 0 || p.Total < min {
+			min = p.Total
+		}
+		if p.Total > max {
+			max = p.Total
+		}
+		total += p.Total
+		days++
+	}
+
+	caption := fmt.Sprintf("Daily V-Bucks from %s to %s: lowest %d, highest %d, average %.0f. Grid lines mark quarters of the highest.",
+		points[0].Date, points[len(points)-1].Date, min, max, float64(total)/float64(days))
+	if missing := len(points) - days; missing > 0 {
+		caption += fmt.Sprintf(" %s without data are marked in red.", plural(missing, "day"))
+	}
+	return caption
+}
+
+// sendChart handles /chart [days] by sending a line chart of the daily totals
+func sendChart(t *tenant, chatID int64, args string) {
+	days := defaultChartDays
+	if arg := strings.TrimSpace(args); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 2 {
+			reply(t.bot, chatID, "Usage: /chart [days], e.g. /chart 30")
+			return
+		}
+		days = n
+	}
+	if days > history.retention {
+		days = history.retention
+	}
+
+	points := dailyTotals(history, days, time.Now())
+	recorded := 0
+	for _, p := range points {
+		if p.OK {
+			recorded++
+		}
+	}
+	if recorded == 0 {
+		reply(t.bot, chatID, "No mission history yet, so there's nothing to chart. Check back after a day of scraping.")
+		return
+	}
+
+	key := chartKey(points)
+	chartCache.mu.Lock()
+	data := chartCache.png
+	if chartCache.key != key {
+		var err error
+		if data, err = renderChart(points); err != nil {
+			chartCache.mu.Unlock()
+			log.Printf("Error rendering chart: %v", err)
+			reply(t.bot, chatID, "Sorry, the chart couldn't be drawn. Please try again later.")
+			return
+		}
+		chartCache.key, chartCache.png = key, data
+	}
+	chartCache.mu.Unlock()
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "vbucks.png", Bytes: data})
+	photo.Caption = chartCaption(points)
+	if _, err := t.bot.Send(photo); err != nil {
+		log.Printf("Error sending chart to chat %d: %v", chatID, err)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// sparsePoints is a week with two gaps, one of them two days long
+var sparsePoints = []chartPoint{
+	{Date: "2024-03-01", Total: 80, OK: true},
+	{Date: "2024-03-02", Total: 130, OK: true},
+	{Date: "2024-03-03"},
+	{Date: "2024-03-04", Total: 50, OK: true},
+	{Date: "2024-03-05"},
+	{Date: "2024-03-06"},
+	{Date: "2024-03-07", Total: 160, OK: true},
+}
+
+// decodePNG decodes a rendered chart
+func decodePNG(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestRenderChartGolden(t *testing.T) {
+	const golden = "testdata/chart-sparse.png"
+	data, err := renderChart(sparsePoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updateGolden {
+		if err := ioutil.WriteFile(golden, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -run RenderChartGolden -update to create it)", err)
+	}
+
+	// Compare pixels, the PNG encoding itself may change between Go releases
+	got, exp := decodePNG(t, data), decodePNG(t, want)
+	if got.Bounds() != exp.Bounds() {
+		t.Fatalf("chart is %v, golden is %v", got.Bounds(), exp.Bounds())
+	}
+	diff := 0
+	for y := got.Bounds().Min.Y; y < got.Bounds().Max.Y; y++ {
+		for x := got.Bounds().Min.X; x < got.Bounds().Max.X; x++ {
+			if got.At(x, y) != exp.At(x, y) {
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%d pixels differ from %s; rerun with -update if the change is intended", diff, golden)
+	}
+}
+
+func TestRenderChartMarksGaps(t *testing.T) {
+	data, err := renderChart(sparsePoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := decodePNG(t, data)
+
+	bottom := chartHeight - chartMargin
+	x := func(i int) int {
+		return chartMargin + (chartWidth-2*chartMargin)*i/(len(sparsePoints)-1)
+	}
+	y := func(total int) int {
+		return bottom - (bottom-chartMargin)*total/160
+	}
+	rgba := func(px, py int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(px, py)).(color.RGBA)
+	}
+
+	for i, p := range sparsePoints {
+		if p.OK {
+			if got := rgba(x(i), y(p.Total)); got != chartLine {
+				t.Errorf("%s has no point at its total", p.Date)
+			}
+			continue
+		}
+		if got := rgba(x(i), bottom); got != chartMissing {
+			t.Errorf("%s has no missing-day mark on the baseline", p.Date)
+		}
+	}
+
+	// Days either side of a gap aren't joined: halfway between 2024-03-04
+	// and 2024-03-07 the plot is blank above the baseline
+	midX := (x(3) + x(6)) / 2
+	for py := chartMargin; py < bottom-3; py++ {
+		if rgba(midX, py) == chartLine {
+			t.Fatalf("line drawn across the gap at (%d, %d)", midX, py)
+		}
+	}
+	// Consecutive days are
+	midY := (y(80) + y(130)) / 2
+	found := false
+	for px := x(0); px <= x(1); px++ {
+		if rgba(px, midY) == chartLine {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("2024-03-01 and 2024-03-02 aren't joined by a line")
+	}
+}
+
+func TestDailyTotalsSparseHistory(t *testing.T) {
+	h := &historyStore{retention: 30, days: map[string][]VBucksMission{
+		"2024-03-01": testMissions,
+		"2024-03-03": {{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"}},
+	}}
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	got := dailyTotals(h, 4, now)
+	want := []chartPoint{
+		{Date: "2024-03-01", Total: 130, OK: true},
+		{Date: "2024-03-02"},
+		{Date: "2024-03-03", Total: 80, OK: true},
+		{Date: "2024-03-04"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dailyTotals =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestChartCaption(t *testing.T) {
+	got := chartCaption(sparsePoints)
+	want := "Daily V-Bucks from 2024-03-01 to 2024-03-07: lowest 50, highest 160, average 105. Grid lines mark quarters of the highest. 3 days without data are marked in red."
+	if got != want {
+		t.Errorf("chartCaption =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Exit codes of the subcommands, so cron jobs and CI can act on the result
+const (
+	exitOK         = 0
+	exitFailure    = 1 // scraping or parsing failed
+	exitConfig     = 2 // bad configuration or usage
+	exitNoMissions = 3 // everything worked but no missions were found
+)
+
+// subcommand runs once instead of the bot and returns an exit code
+type subcommand struct {
+	Usage string
+	Run   func(args []string) int
+}
+
+// subcommands are run with "stw-missions-scraper <name> [args]"
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"parse-saved-html": {
+			Usage: "parse-saved-html [path]  re-run the parser on a saved page",
+			Run:   runParseSavedHTML,
+		},
+		"dry-run": {
+			Usage: "dry-run                  scrape the sources once and print the missions",
+			Run:   runDryRun,
+		},
+		"backup": {
+			Usage: "backup [--ndjson]        write the mission history to stdout",
+			Run:   runBackup,
+		},
+	}
+}
+
+// runSubcommand loads the configuration and runs the named subcommand
+func runSubcommand(name string, args []string) int {
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown subcommand %q\n\n%s", name, subcommandUsage())
+		return exitConfig
+	}
+
+	if err := loadConfig(); err != nil {
+		log.Print(err)
+		return exitConfig
+	}
+
+	return cmd.Run(args)
+}
+
+// subcommandUsage lists the subcommands and the exit codes they use
+func subcommandUsage() string {
+	var b strings.Builder
+	b.WriteString("Subcommands:\n")
+	for _, name := range []string{"parse-saved-html", "dry-run", "backup"} {
+		b.WriteString("  " + subcommands[name].Usage + "\n")
+	}
+	b.WriteString("\nExit codes: 0 success, 1 scrape/parse failure, 2 config error, 3 no missions found\n")
+	return b.String()
+}
+
+// printMissions lists missions one per line, flagging suspect ones
+func printMissions(missions []VBucksMission) {
+	for i, mission := range missions {
+		suspect := ""
+		if mission.Suspect {
+			suspect = " [suspect: " + mission.suspectReason() + "]"
+		}
+		fmt.Printf("%d. %s%s\n", i+1, missionLine(mission), suspect)
+	}
+	fmt.Printf("Total: %d V-Bucks\n", totalVBucks(missions))
+}
+
+// runParseSavedHTML handles "parse-saved-html [path]"
+func runParseSavedHTML(args []string) int {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	missions, err := parseSavedHTML(path)
+	if err != nil {
+		log.Print(err)
+		return exitFailure
+	}
+	if len(missions) == 0 {
+		return exitNoMissions
+	}
+	return exitOK
+}
+
+// runDryRun handles "dry-run", scraping without touching the cache or history
+func runDryRun(args []string) int {
+	missions := fixtureMissions
+	if !fixtureMode {
+		var err error
+		missions, _, err = fetchMissions()
+		if err != nil {
+			log.Print(err)
+			return exitFailure
+		}
+	}
+
+	fmt.Printf("Fetched %d missions:\n", len(missions))
+	printMissions(missions)
+
+	if len(missions) == 0 {
+		return exitNoMissions
+	}
+	return exitOK
+}
+
+// runBackup handles "backup [--ndjson]"
+func runBackup(args []string) int {
+	ndjson := false
+	for _, arg := range args {
+		if arg != "--ndjson" {
+			fmt.Fprintf(os.Stderr, "Unknown option %q\n\n%s", arg, subcommandUsage())
+			return exitConfig
+		}
+		ndjson = true
+	}
+
+	if len(history.dates()) == 0 {
+		log.Print("No mission history to back up")
+		return exitNoMissions
+	}
+
+	if err := history.writeJSON(os.Stdout, ndjson); err != nil {
+		log.Printf("Error writing history: %v", err)
+		return exitFailure
+	}
+	return exitOK
+}
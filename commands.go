@@ -0,0 +1,982 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Command is anything the registry can dispatch to
+// Built-in commands are botCommands; forks can add their own types with
+// commands.add without touching the dispatch code
+type Command interface {
+	// Spec describes the command for the registry, /help and the menu
+	Spec() commandSpec
+	// Run handles a message invoking the command
+	Run(t *tenant, msg *tgbotapi.Message)
+}
+
+// role is who may run a command; each role includes the ones before it
+type role int
+
+const (
+	// rolePublic commands run for anyone
+	rolePublic role = iota
+	// roleSubscriber commands only make sense for chats getting the daily push
+	roleSubscriber
+	// roleAdmin commands are hidden from /help and the menu and only run for
+	// the admin chat
+	roleAdmin
+)
+
+// roleOf returns the role of a chat: admin for ADMIN_CHAT_ID, subscriber for
+// chats subscribed to the daily push, public otherwise
+func roleOf(store *preferenceStore, chatID int64) role {
+	switch {
+	case isAdmin(chatID):
+		return roleAdmin
+	case store.get(chatID).Subscribed:
+		return roleSubscriber
+	}
+	return rolePublic
+}
+
+// commandSpec describes a command
+type commandSpec struct {
+	Name string
+	// Args is shown after the command name in /help, e.g. "<area|all>"
+	Args        string
+	Description string
+	// Role is the least role a chat needs to run the command
+	Role role
+}
+
+// botCommand is a Command made of the commandSpec fields and a handler
+// function, so each built-in is a single literal
+type botCommand struct {
+	Name        string
+	Args        string
+	Description string
+	Role        role
+	Handle      func(t *tenant, msg *tgbotapi.Message)
+}
+
+// Spec implements Command
+func (c *botCommand) Spec() commandSpec {
+	return commandSpec{Name: c.Name, Args: c.Args, Description: c.Description, Role: c.Role}
+}
+
+// Run implements Command
+func (c *botCommand) Run(t *tenant, msg *tgbotapi.Message) {
+	c.Handle(t, msg)
+}
+
+// commandRegistry maps command names to their handlers, keeping the order they
+// were registered in so /help and the menu list them predictably
+type commandRegistry struct {
+	byName map[string]Command
+	order  []Command
+}
+
+// commands holds every command the bot understands
+var commands = &commandRegistry{byName: map[string]Command{}}
+
+// add puts a command in the registry
+func (r *commandRegistry) add(cmd Command) {
+	name := cmd.Spec().Name
+	if _, exists := r.byName[name]; exists {
+		log.Fatalf("Command /%s registered twice", name)
+	}
+	r.byName[name] = cmd
+	r.order = append(r.order, cmd)
+}
+
+// register adds a built-in command to the registry
+func (r *commandRegistry) register(cmd botCommand) {
+	r.add(&cmd)
+}
+
+// lookup returns the command with the given name, if any
+func (r *commandRegistry) lookup(name string) (Command, bool) {
+	cmd, ok := r.byName[name]
+	return cmd, ok
+}
+
+// public returns the commands listed in /help and the Telegram menu
+func (r *commandRegistry) public() []Command {
+	var cmds []Command
+	for _, cmd := range r.order {
+		if cmd.Spec().Role < roleAdmin {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// dispatch runs the handler for a command message
+func (r *commandRegistry) dispatch(t *tenant, msg *tgbotapi.Message) {
+	cmd, ok := r.lookup(msg.Command())
+	if !ok {
+		// The chat may have its own name for the command
+		if target, aliased := t.prefs.get(msg.Chat.ID).Aliases[strings.ToLower(msg.Command())]; aliased {
+			cmd, ok = r.lookup(target)
+		}
+	}
+	if !ok {
+		reply(t.bot, msg.Chat.ID, "Unknown command. Try /help")
+		return
+	}
+
+	switch spec := cmd.Spec(); {
+	case spec.Role <= rolePublic:
+	case spec.Role == roleAdmin && !isAdmin(msg.Chat.ID):
+		// Admin commands aren't advertised, so don't reveal they exist
+		reply(t.bot, msg.Chat.ID, "Unknown command. Try /help")
+		return
+	case roleOf(t.prefs, msg.Chat.ID) < spec.Role:
+		reply(t.bot, msg.Chat.ID, fmt.Sprintf("/%s changes the daily notifications. Use /subscribe first.", spec.Name))
+		return
+	}
+	cmd.Run(t, msg)
+}
+
+// helpText lists the public commands
+func (r *commandRegistry) helpText() string {
+	var result strings.Builder
+	result.WriteString("Available commands:\n")
+	for _, cmd := range r.public() {
+		spec := cmd.Spec()
+		result.WriteString("/" + spec.Name)
+		if spec.Args != "" {
+			result.WriteString(" " + spec.Args)
+		}
+		result.WriteString(" - " + spec.Description + "\n")
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// setMenu registers the public commands with Telegram so clients show them
+// in the command menu
+func (r *commandRegistry) setMenu(bot *tgbotapi.BotAPI) error {
+	var menu []tgbotapi.BotCommand
+	for _, cmd := range r.public() {
+		spec := cmd.Spec()
+		menu = append(menu, tgbotapi.BotCommand{Command: spec.Name, Description: spec.Description})
+	}
+	_, err := bot.Request(tgbotapi.NewSetMyCommands(menu...))
+	return err
+}
+
+func init() {
+	commands.register(botCommand{
+		Name:        "start",
+		Description: "Show the welcome message and today's missions",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			// Links made with /share open the bot on the shared day
+			if date, ok := parseSharePayload(msg.CommandArguments()); ok {
+				showMissions(t, msg.Chat.ID, date)
+				return
+			}
+
+			configMu.RLock()
+			welcome, markdown := welcomeMessage, welcomeMarkdown
+			configMu.RUnlock()
+
+			// Send welcome message and show missions
+			if markdown {
+				replyMarkdown(t.bot, msg.Chat.ID, welcome)
+			} else {
+				reply(t.bot, msg.Chat.ID, welcome)
+			}
+
+			// Send V-Bucks missions
+			missions, freshness := getMissions()
+			replyMarkdown(t.bot, msg.Chat.ID, formatMissionsForChat(t.prefs.get(msg.Chat.ID), formatOptions{Freshness: freshness}, missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "vbucks",
+		Args:        "[YYYY-MM-DD]",
+		Description: "Show today's V-Bucks missions, or a past day's",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			showMissions(t, msg.Chat.ID, msg.CommandArguments())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "mission",
+		Args:        "<number>",
+		Description: "Show the details of one of today's missions",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, missionDetail(t.prefs.get(msg.Chat.ID), msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "mypl",
+		Args:        "<power level>",
+		Description: "Store your power level for /forme",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setMyPowerLevel(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "forme",
+		Description: "List today's missions you can do at your power level",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			showMissionsForMe(t, msg.Chat.ID)
+		},
+	})
+	commands.register(botCommand{
+		Name:        "amount",
+		Args:        "<n>",
+		Description: "List the missions worth exactly n V-Bucks",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			showAmountMissions(t, msg.Chat.ID, msg.CommandArguments())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "share",
+		Args:        "[YYYY-MM-DD]",
+		Description: "Get a link that opens the bot on a day's missions",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, shareLink(t.bot.Self.UserName, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "speak",
+		Description: "Hear today's missions as a voice message",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			sendSpokenMissions(t, msg.Chat.ID)
+		},
+	})
+	commands.register(botCommand{
+		Name:        "vbucksshort",
+		Description: "Show today's mission count and total in one line",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, freshness := getMissions()
+			if freshness.unavailable() {
+				reply(t.bot, msg.Chat.ID, fallbackText())
+				return
+			}
+			reply(t.bot, msg.Chat.ID, formatMissionsSummary(t.prefs.get(msg.Chat.ID), missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "plfilter",
+		Args:        "<pl>=X|pl<=X|off>",
+		Description: "Only show missions within a power level",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setPowerLevelFilter(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "settotal",
+		Args:        "<on|off>",
+		Description: "Show or hide the total line",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setShowTotal(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "detail",
+		Args:        "<compact|verbose>",
+		Description: "Choose how much each mission line shows",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setDetail(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "onlynew",
+		Args:        "<on|off>",
+		Description: "Only push missions you haven't been sent before",
+		Role:        roleSubscriber,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setOnlyNew(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "weekendsonly",
+		Args:        "<on|off>",
+		Description: "Only get the daily missions on weekends",
+		Role:        roleSubscriber,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setWeekendsOnly(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "notifymode",
+		Args:        "<full|summary|digest>",
+		Description: "Choose a full list, a summary, or only above-average days",
+		Role:        roleSubscriber,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setNotifyMode(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "keywords",
+		Args:        "<on|off>",
+		Description: "Answer messages asking for V-Bucks without a slash command",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setKeywords(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "shortrepeats",
+		Args:        "<on|off>",
+		Description: "Get a short note when the missions repeat yesterday's",
+		Role:        roleSubscriber,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setShortRepeats(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "poll",
+		Description: "Start a poll on which mission to do",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			sendMissionPoll(t, msg.Chat.ID)
+		},
+	})
+	commands.register(botCommand{
+		Name:        "goal",
+		Args:        "[amount|off]",
+		Description: "Set a V-Bucks goal to work towards",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setGoal(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "progress",
+		Description: "Show your goal progress and mark missions done",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			sendProgress(t, msg.Chat.ID)
+		},
+	})
+	commands.register(botCommand{
+		Name:        "time",
+		Description: "List missions by V-Bucks per minute",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			reply(t.bot, msg.Chat.ID, formatMissionTimes(missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "rate",
+		Description: "Work out the most V-Bucks an hour of play gets today",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			reply(t.bot, msg.Chat.ID, formatRate(missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "brackets",
+		Description: "Count today's missions by power level",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			replyMarkdown(t.bot, msg.Chat.ID, formatBrackets(missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "upcoming",
+		Description: "Show missions the source lists for later days",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			// Make sure the cache holds today's scrape before reading it back
+			getMissions()
+			cachedData, _ := loadFromCache()
+			reply(t.bot, msg.Chat.ID, formatUpcoming(cachedData.Upcoming, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "whoami",
+		Description: "Show this chat's ID and your user ID, for setting up the bot",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatWhoAmI(msg))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "tiers",
+		Description: "Count today's missions by reward",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			reply(t.bot, msg.Chat.ID, formatTiers(missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "efficient",
+		Description: "List missions by V-Bucks per power level",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			reply(t.bot, msg.Chat.ID, formatEfficientMissions(missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "subscribe",
+		Description: "Get the missions every day after the reset",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setSubscribed(t.prefs, msg.Chat.ID, true))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "unsubscribe",
+		Description: "Stop the daily notifications",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setSubscribed(t.prefs, msg.Chat.ID, false))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "remindat",
+		Args:        "<HH:MM|off>",
+		Description: "Get a reminder to check the missions every day at a set time",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setRemindAt(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "timezone",
+		Args:        "<zone>",
+		Description: "Set your time zone for /remindat",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setTimezone(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "onlyarea",
+		Args:        "<area|all>",
+		Description: "Only show missions in one area",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setAreaFilter(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "source",
+		Args:        "<name>",
+		Description: "Show the missions reported by one source",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			showSourceMissions(t, msg.Chat.ID, msg.CommandArguments())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "settings",
+		Description: "Show your current settings",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatSettings(t.prefs.get(msg.Chat.ID)))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "exportprefs",
+		Description: "Get a code that copies your settings to another chat",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, handleExportPrefs(t.prefs, msg.Chat.ID))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "importprefs",
+		Args:        "<code>",
+		Description: "Apply settings exported with /exportprefs",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, handleImportPrefs(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "reset",
+		Description: "Restore the default settings",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			askReset(t.bot, msg.Chat.ID)
+		},
+	})
+	commands.register(botCommand{
+		Name:        "alias",
+		Args:        "[name] [command|off]",
+		Description: "List, add or remove your own command shortcuts",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setAlias(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "suspect",
+		Description: "List missions the parser wasn't confident about",
+		Role:        roleAdmin,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			reply(t.bot, msg.Chat.ID, formatSuspectMissions(missions))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "watchtype",
+		Args:        "<mission type>",
+		Description: "Get a ping the next day a mission type appears",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setWatchType(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "unwatchtype",
+		Args:        "<mission type>",
+		Description: "Stop watching a mission type",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, unsetWatchType(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "compareday",
+		Args:        "<YYYY-MM-DD>",
+		Description: "Compare today's missions with a past day",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, compareDay(msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "amountchanges",
+		Description: "Show the missions whose reward changed since yesterday",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, _ := getMissions()
+			reply(t.bot, msg.Chat.ID, formatAmountChanges(missions, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "calendar",
+		Description: "Show the last weeks as a calendar shaded by V-Bucks",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatCalendar(history, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "chart",
+		Args:        "[days]",
+		Description: "Chart the daily V-Bucks totals",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			sendChart(t, msg.Chat.ID, msg.CommandArguments())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "projection",
+		Description: "Estimate the V-Bucks on offer this week",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatProjection(history, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "worth",
+		Args:        "[V-Bucks|off]",
+		Description: "Say whether today's missions are worth playing",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, handleWorth(t.prefs, msg.Chat.ID, msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "lasthigh",
+		Args:        "<area> [V-Bucks]",
+		Description: "Show when an area last had a high-value mission",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatLastHigh(history, msg.CommandArguments(), time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "season",
+		Args:        "<YYYY-MM-DD|off>",
+		Description: "Set the day your season started",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setSeason(t.prefs, msg.Chat.ID, msg.CommandArguments(), time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "seasontotal",
+		Description: "Sum the V-Bucks available since your season started",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatSeasonTotal(history, t.prefs.get(msg.Chat.ID), time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "rare",
+		Description: "Show the mission types seen least often lately",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatRare(history, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "streak",
+		Args:        "<type>",
+		Description: "Show how many days in a row a mission type has been up",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatStreak(history, msg.CommandArguments(), time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "when",
+		Description: "Show when the missions rotate next",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			getMissions()
+			cachedData, _ := loadFromCache()
+			reply(t.bot, msg.Chat.ID, formatWhen(cachedData, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "health",
+		Description: "Show at a glance whether the mission data can be trusted",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			missions, freshness := getMissions()
+			reply(t.bot, msg.Chat.ID, formatHealth(missions, freshness, time.Now()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "stats",
+		Description: "Show how the last scrape went",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, maintenance.status()+fetchStatus.format())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "maintenance",
+		Args:        "<duration|off>",
+		Description: "Pause scraping during source downtime",
+		Role:        roleAdmin,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setMaintenance(msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "refreshall",
+		Description: "Scrape now and notify every subscriber if the missions changed",
+		Role:        roleAdmin,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, "Refreshing, this can take a moment...")
+			reply(t.bot, msg.Chat.ID, refreshAll())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "label",
+		Args:        "<chat ID> [name]",
+		Description: "Name a chat in admin views",
+		Role:        roleAdmin,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, setLabel(msg.CommandArguments()))
+		},
+	})
+	commands.register(botCommand{
+		Name:        "deadletters",
+		Description: "List recent messages that couldn't be delivered",
+		Role:        roleAdmin,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, formatDeadLetters())
+		},
+	})
+	commands.register(botCommand{
+		Name:        "backup",
+		Description: "Send the whole mission history as a JSON file",
+		Role:        roleAdmin,
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			sendHistoryBackup(t.bot, msg.Chat.ID)
+		},
+	})
+	commands.register(botCommand{
+		Name:        "help",
+		Description: "Show this help message",
+		Handle: func(t *tenant, msg *tgbotapi.Message) {
+			reply(t.bot, msg.Chat.ID, commands.helpText())
+		},
+	})
+}
+
+// reply sends a plain text message to the chat
+func reply(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
+
+// replyMarkdown sends a MarkdownV2 formatted message to the chat
+func replyMarkdown(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "MarkdownV2"
+	send(bot, msg)
+}
+
+// send delivers a message, recording it as a dead letter if Telegram rejects
+// it for a reason other than being blocked
+// MarkdownV2 messages Telegram can't parse are resent as plain text so the
+// user still gets the content
+func send(bot *tgbotapi.BotAPI, msg tgbotapi.MessageConfig) error {
+	_, err := bot.Send(msg)
+	if err != nil && msg.ParseMode == "MarkdownV2" && isParseError(err) {
+		log.Printf("Telegram rejected MarkdownV2 for chat %d, falling back to plain text: %v", msg.ChatID, err)
+		msg.ParseMode = ""
+		msg.Text = stripMarkdownV2(msg.Text)
+		_, err = bot.Send(msg)
+	}
+	if err != nil {
+		statsd.count(metricMessagesFailed, 1)
+		if isBlocked(err) || isTransient(err) {
+			log.Printf("Error sending message to chat %d: %v", msg.ChatID, err)
+		} else {
+			recordDeadLetter(msg.ChatID, msg.Text, err)
+		}
+	} else {
+		statsd.count(metricMessagesSent, 1)
+	}
+	return err
+}
+
+// isParseError reports whether Telegram refused a message because its
+// formatting entities couldn't be parsed
+func isParseError(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(tgErr.Message), "can't parse entities")
+}
+
+// setAreaFilter handles /onlyarea and returns the reply text
+func setAreaFilter(store *preferenceStore, chatID int64, args string) string {
+	area := strings.TrimSpace(args)
+
+	if area == "" {
+		if current := store.get(chatID).Area; current != "" {
+			return fmt.Sprintf("Only showing missions in %q. Use /onlyarea all to show every area.", current)
+		}
+		return "Usage: /onlyarea <area>, e.g. /onlyarea Twine. Use /onlyarea all to show every area."
+	}
+
+	if strings.EqualFold(area, "all") {
+		area = ""
+	} else {
+		area = resolveArea(area)
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.Area = area }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if area == "" {
+		return "Showing missions in every area."
+	}
+	return fmt.Sprintf("Only showing missions in areas matching %q.", area)
+}
+
+// powerLevelFilterPattern matches one "pl>=X" or "pl<=X" filter term
+var powerLevelFilterPattern = regexp.MustCompile(`^(?i)pl\s*(>=|<=)\s*(\d+)$`)
+
+// setPowerLevelFilter handles /plfilter and returns the reply text
+// Terms can be combined, e.g. "/plfilter pl>=40 pl<=80"
+func setPowerLevelFilter(store *preferenceStore, chatID int64, args string) string {
+	usage := "Usage: /plfilter pl>=X, /plfilter pl<=X, both (e.g. /plfilter pl>=40 pl<=80) or /plfilter off."
+
+	terms := strings.Fields(args)
+	if len(terms) == 0 {
+		return usage
+	}
+
+	minLevel, maxLevel := 0, 0
+	if !(len(terms) == 1 && strings.EqualFold(terms[0], "off")) {
+		for _, term := range terms {
+			match := powerLevelFilterPattern.FindStringSubmatch(term)
+			if match == nil {
+				return usage
+			}
+			level, _ := strconv.Atoi(match[2])
+			if match[1] == ">=" {
+				minLevel = level
+			} else {
+				maxLevel = level
+			}
+		}
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) {
+		p.MinPowerLevel = minLevel
+		p.MaxPowerLevel = maxLevel
+	}); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if minLevel == 0 && maxLevel == 0 {
+		return "Showing missions of every power level."
+	}
+	return "Power level filter saved. Missions whose range overlaps it will be shown."
+}
+
+// showMissions handles /vbucks: today's missions from the cache, or a past
+// day's from history when a date is given
+func showMissions(t *tenant, chatID int64, args string) {
+	arg := strings.TrimSpace(args)
+	if arg == "" || arg == time.Now().UTC().Format(dateLayout) {
+		missions, freshness := getMissions()
+		replyMarkdown(t.bot, chatID, formatMissionsForChat(t.prefs.get(chatID), formatOptions{Freshness: freshness}, missions))
+		return
+	}
+
+	date, err := history.parseHistoryDate(arg)
+	if err != nil {
+		reply(t.bot, chatID, "Can't show that day: "+err.Error()+".")
+		return
+	}
+
+	missions, ok := history.missionsOn(date)
+	if !ok {
+		reply(t.bot, chatID, fmt.Sprintf("No missions were recorded on %s.", date))
+		return
+	}
+
+	replyMarkdown(t.bot, chatID, formatMissionsForChat(t.prefs.get(chatID), formatOptions{Day: date}, missions))
+}
+
+// missionDetail handles /mission: everything known about one mission, numbered
+// as in the chat's /vbucks list
+func missionDetail(prefs ChatPreferences, args string) string {
+	missions, _ := getMissions()
+	missions = filterForChat(prefs, missions)
+
+	n, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil || n < 1 || n > len(missions) {
+		if len(missions) == 0 {
+			return "No V-Bucks missions found today."
+		}
+		return fmt.Sprintf("Usage: /mission <number>, from 1 to %d as listed by /vbucks", len(missions))
+	}
+	mission := missions[n-1]
+
+	modifiers := "none listed"
+	if len(mission.Modifiers) > 0 {
+		modifiers = strings.Join(mission.Modifiers, ", ")
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Mission %d of %d\n", n, len(missions)))
+	result.WriteString(fmt.Sprintf("Type: %s\n", mission.MissionType))
+	result.WriteString(fmt.Sprintf("Area: %s\n", mission.Area))
+	result.WriteString(fmt.Sprintf("Power level: %s\n", mission.PowerLevel))
+	result.WriteString(fmt.Sprintf("Reward: %s V-Bucks\n", mission.Amount))
+	result.WriteString(fmt.Sprintf("Modifiers: %s", modifiers))
+	if mission.Suspect {
+		result.WriteString("\n\n⚠️ Couldn't fully parse: " + mission.suspectReason())
+	}
+
+	return result.String()
+}
+
+// sourceNames lists the configured source names, comma-separated
+func sourceNames() string {
+	configured := configuredSources()
+	names := make([]string, 0, len(configured))
+	for _, src := range configured {
+		names = append(names, src.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// showSourceMissions handles /source: the missions a single source reported
+// in the last scrape, before they were merged with the other sources
+func showSourceMissions(t *tenant, chatID int64, args string) {
+	name := strings.TrimSpace(args)
+
+	known := false
+	for _, src := range configuredSources() {
+		if strings.EqualFold(src.Name, name) {
+			name, known = src.Name, true
+			break
+		}
+	}
+	if !known {
+		reply(t.bot, chatID, "Usage: /source <name>\nSources: "+sourceNames())
+		return
+	}
+
+	// Make sure the cache holds today's scrape before reading it back
+	getMissions()
+	cachedData, _ := loadFromCache()
+
+	missions, ok := cachedData.Sources[name]
+	if !ok {
+		reply(t.bot, chatID, fmt.Sprintf("%s didn't return any missions in the last scrape; see /stats for its last error.", name))
+		return
+	}
+
+	replyMarkdown(t.bot, chatID, "*"+escapeMarkdown("From "+name)+"*\n\n"+formatMissionList(formatOptions{}, missions))
+}
+
+// sendHistoryBackup sends the exported history to the chat as a document
+func sendHistoryBackup(bot *tgbotapi.BotAPI, chatID int64) {
+	var buf bytes.Buffer
+	if err := history.writeJSON(&buf, false); err != nil {
+		log.Printf("Error exporting history: %v", err)
+		reply(bot, chatID, "Sorry, the history couldn't be exported.")
+		return
+	}
+
+	name := "vbucks_history_" + time.Now().UTC().Format(dateLayout) + ".json"
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: buf.Bytes()})
+	if _, err := bot.Send(doc); err != nil {
+		log.Printf("Error sending history backup: %v", err)
+	}
+}
+
+// setShowTotal handles /settotal and returns the reply text
+func setShowTotal(store *preferenceStore, chatID int64, args string) string {
+	var hide bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		hide = false
+	case "off":
+		hide = true
+	default:
+		return "Usage: /settotal on or /settotal off"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.HideTotal = hide }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if hide {
+		return "The total line is now hidden."
+	}
+	return "The total line is now shown."
+}
+
+// setDetail handles /detail and returns the reply text
+func setDetail(store *preferenceStore, chatID int64, args string) string {
+	var compact bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "compact":
+		compact = true
+	case "verbose":
+		compact = false
+	default:
+		return "Usage: /detail compact or /detail verbose"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.Compact = compact }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if compact {
+		return "Mission lines now only show the reward and area."
+	}
+	return "Mission lines now show the power level and mission type too."
+}
+
+// compareDay handles /compareday and returns the reply text
+func compareDay(args string) string {
+	arg := strings.TrimSpace(args)
+	if arg == "" {
+		return "Usage: /compareday <YYYY-MM-DD>, e.g. /compareday " + time.Now().UTC().AddDate(0, 0, -1).Format(dateLayout)
+	}
+
+	date, err := history.parseHistoryDate(arg)
+	if err != nil {
+		return "Can't compare: " + err.Error() + "."
+	}
+
+	past, ok := history.missionsOn(date)
+	if !ok {
+		return fmt.Sprintf("No missions were recorded on %s.", date)
+	}
+
+	today, _ := getMissions()
+	return formatComparison(date, past, today)
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// telegramRequest is a Bot API call received by fakeTelegram
+type telegramRequest struct {
+	Method string
+	Form   url.Values
+}
+
+// fakeTelegram is a Bot API server that records the calls it gets. fail, if
+// set, picks an error code and description to answer a call with; 0 succeeds
+type fakeTelegram struct {
+	mu       sync.Mutex
+	requests []telegramRequest
+	fail     func(req telegramRequest) (int, string)
+}
+
+// newFakeTelegram starts a fake Bot API server and returns a bot talking to it
+func newFakeTelegram(t *testing.T) (*fakeTelegram, *tgbotapi.BotAPI) {
+	t.Helper()
+	fake := &fakeTelegram{}
+	srv := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("TOKEN", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, bot
+}
+
+func (f *fakeTelegram) serve(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		r.ParseMultipartForm(1 << 20)
+	} else {
+		r.ParseForm()
+	}
+	req := telegramRequest{Method: path.Base(r.URL.Path), Form: r.Form}
+
+	f.mu.Lock()
+	fail := f.fail
+	if req.Method != "getMe" {
+		f.requests = append(f.requests, req)
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if fail != nil && req.Method != "getMe" {
+		if code, desc := fail(req); code != 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": code, "description": desc})
+			return
+		}
+	}
+	switch req.Method {
+	case "getMe":
+		io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"testbot"}}`)
+	case "sendMessage":
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":1,"chat":{"id":%s}}}`, req.Form.Get("chat_id"))
+	default:
+		io.WriteString(w, `{"ok":true,"result":true}`)
+	}
+}
+
+// sent returns the texts of the messages sent so far
+func (f *fakeTelegram) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var texts []string
+	for _, req := range f.requests {
+		if req.Method == "sendMessage" {
+			texts = append(texts, req.Form.Get("text"))
+		}
+	}
+	return texts
+}
+
+// setFail replaces the failure hook
+func (f *fakeTelegram) setFail(fail func(req telegramRequest) (int, string)) {
+	f.mu.Lock()
+	f.fail = fail
+	f.mu.Unlock()
+}
+
+func TestMissionDetailModifiers(t *testing.T) {
+	withFixture(t, []VBucksMission{
+		{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks", Modifiers: []string{"Fire Storm", "Smashers"}},
+		{Amount: "50", PowerLevel: "64", MissionType: "Retrieve the Data", Area: "Plankerton"},
+	})
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{args: "1", want: "Modifiers: Fire Storm, Smashers"},
+		{args: "2", want: "Modifiers: none listed"},
+		{args: "3", want: "Usage: /mission <number>, from 1 to 2"},
+	}
+	for _, tt := range tests {
+		if got := missionDetail(ChatPreferences{}, tt.args); !strings.Contains(got, tt.want) {
+			t.Errorf("/mission %s = %q, want it to contain %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// fakeCommand is a Command recording the messages it's run with
+type fakeCommand struct {
+	spec commandSpec
+	ran  []string
+}
+
+func (c *fakeCommand) Spec() commandSpec { return c.spec }
+
+func (c *fakeCommand) Run(t *tenant, msg *tgbotapi.Message) {
+	c.ran = append(c.ran, msg.Text)
+}
+
+// newTestRegistry returns a registry holding only the given commands
+func newTestRegistry(cmds ...Command) *commandRegistry {
+	r := &commandRegistry{byName: map[string]Command{}}
+	for _, cmd := range cmds {
+		r.add(cmd)
+	}
+	return r
+}
+
+// commandMessage builds a message invoking a command, as Telegram sends it
+func commandMessage(chatID int64, text string) *tgbotapi.Message {
+	name := strings.Fields(text)[0]
+	return &tgbotapi.Message{
+		Text:     text,
+		Chat:     &tgbotapi.Chat{ID: chatID, Type: "private"},
+		From:     &tgbotapi.User{ID: chatID},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(name)}},
+	}
+}
+
+// withAdmin makes chatID the admin chat for the test
+func withAdmin(t *testing.T, chatID int64) {
+	t.Helper()
+	configMu.Lock()
+	old := adminChatID
+	adminChatID = chatID
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		adminChatID = old
+		configMu.Unlock()
+	})
+}
+
+func TestRegistryDispatchesAddedCommand(t *testing.T) {
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	echo := &fakeCommand{spec: commandSpec{Name: "echo", Args: "<text>", Description: "Repeat the text"}}
+	r := newTestRegistry(echo)
+
+	if cmd, ok := r.lookup("echo"); !ok || cmd != Command(echo) {
+		t.Fatalf("lookup(echo) = %v, %v", cmd, ok)
+	}
+
+	r.dispatch(tn, commandMessage(7, "/echo hello"))
+	if want := []string{"/echo hello"}; !reflect.DeepEqual(echo.ran, want) {
+		t.Errorf("echo ran with %q, want %q", echo.ran, want)
+	}
+	if sent := fake.sent(); len(sent) != 0 {
+		t.Errorf("dispatch replied %q to a known command", sent)
+	}
+
+	r.dispatch(tn, commandMessage(7, "/nope"))
+	if sent := fake.sent(); len(sent) != 1 || sent[0] != "Unknown command. Try /help" {
+		t.Errorf("reply to an unknown command = %q", sent)
+	}
+	if len(echo.ran) != 1 {
+		t.Errorf("echo ran for /nope")
+	}
+}
+
+func TestRegistryListsPublicCommands(t *testing.T) {
+	fake, bot := newFakeTelegram(t)
+	r := newTestRegistry(
+		&fakeCommand{spec: commandSpec{Name: "echo", Args: "<text>", Description: "Repeat the text"}},
+		&fakeCommand{spec: commandSpec{Name: "mine", Description: "Subscriber only", Role: roleSubscriber}},
+		&fakeCommand{spec: commandSpec{Name: "secret", Description: "Admin only", Role: roleAdmin}},
+	)
+
+	want := "Available commands:\n/echo <text> - Repeat the text\n/mine - Subscriber only"
+	if got := r.helpText(); got != want {
+		t.Errorf("helpText =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := r.setMenu(bot); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.requests) != 1 || fake.requests[0].Method != "setMyCommands" {
+		t.Fatalf("setMenu made requests %+v", fake.requests)
+	}
+	var menu []tgbotapi.BotCommand
+	if err := json.Unmarshal([]byte(fake.requests[0].Form.Get("commands")), &menu); err != nil {
+		t.Fatal(err)
+	}
+	if len(menu) != 2 || menu[0].Command != "echo" || menu[1].Command != "mine" {
+		t.Errorf("menu = %+v, want echo and mine", menu)
+	}
+}
+
+func TestBuiltinCommandsHaveSpecs(t *testing.T) {
+	for _, cmd := range commands.order {
+		spec := cmd.Spec()
+		if spec.Name == "" || spec.Description == "" {
+			t.Errorf("command %+v has no name or description", spec)
+		}
+		if spec.Name != strings.ToLower(spec.Name) {
+			t.Errorf("/%s isn't lowercase, Telegram's menu rejects it", spec.Name)
+		}
+		if found, _ := commands.lookup(spec.Name); found != cmd {
+			t.Errorf("/%s doesn't look up to itself", spec.Name)
+		}
+	}
+}
+
+func TestDispatchChecksRoles(t *testing.T) {
+	const (
+		adminChat      = 100
+		subscriberChat = 200
+		publicChat     = 300
+	)
+	withAdmin(t, adminChat)
+
+	subscriberOnly := "/mine changes the daily notifications. Use /subscribe first."
+	unknown := "Unknown command. Try /help"
+	tests := []struct {
+		name      string
+		chatID    int64
+		text      string
+		wantRun   string
+		wantReply string
+	}{
+		{name: "public command from anyone", chatID: publicChat, text: "/echo", wantRun: "echo"},
+		{name: "subscriber command from subscriber", chatID: subscriberChat, text: "/mine", wantRun: "mine"},
+		{name: "subscriber command from non-subscriber", chatID: publicChat, text: "/mine", wantReply: subscriberOnly},
+		{name: "subscriber command from admin", chatID: adminChat, text: "/mine", wantRun: "mine"},
+		{name: "admin command from admin", chatID: adminChat, text: "/secret", wantRun: "secret"},
+		{name: "admin command from subscriber", chatID: subscriberChat, text: "/secret", wantReply: unknown},
+		{name: "admin command from non-admin", chatID: publicChat, text: "/secret", wantReply: unknown},
+		{name: "alias to admin command from non-admin", chatID: publicChat, text: "/s", wantReply: unknown},
+		{name: "alias to admin command from admin", chatID: adminChat, text: "/s", wantRun: "secret"},
+		{name: "alias to subscriber command from non-subscriber", chatID: publicChat, text: "/m", wantReply: subscriberOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, bot := newFakeTelegram(t)
+			tn := &tenant{bot: bot, prefs: newTestStore(t)}
+			if err := tn.prefs.update(subscriberChat, func(p *ChatPreferences) { p.Subscribed = true }); err != nil {
+				t.Fatal(err)
+			}
+			aliases := map[string]string{"s": "secret", "m": "mine"}
+			for _, chatID := range []int64{adminChat, subscriberChat, publicChat} {
+				if err := tn.prefs.update(chatID, func(p *ChatPreferences) { p.Aliases = aliases }); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmds := map[string]*fakeCommand{
+				"echo":   {spec: commandSpec{Name: "echo", Role: rolePublic}},
+				"mine":   {spec: commandSpec{Name: "mine", Role: roleSubscriber}},
+				"secret": {spec: commandSpec{Name: "secret", Role: roleAdmin}},
+			}
+			r := newTestRegistry(cmds["echo"], cmds["mine"], cmds["secret"])
+			r.dispatch(tn, commandMessage(tt.chatID, tt.text))
+
+			for name, cmd := range cmds {
+				if ran := len(cmd.ran) > 0; ran != (name == tt.wantRun) {
+					t.Errorf("/%s ran = %v", name, ran)
+				}
+			}
+			sent := fake.sent()
+			if tt.wantReply == "" {
+				if len(sent) != 0 {
+					t.Errorf("replied %q, want the command to run silently", sent)
+				}
+			} else if len(sent) != 1 || sent[0] != tt.wantReply {
+				t.Errorf("replied %q, want %q", sent, tt.wantReply)
+			}
+		})
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	withAdmin(t, 100)
+	store := newTestStore(t)
+	for _, chatID := range []int64{100, 200} {
+		if err := store.update(chatID, func(p *ChatPreferences) { p.Subscribed = true }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[int64]role{100: roleAdmin, 200: roleSubscriber, 300: rolePublic}
+	for chatID, want := range tests {
+		if got := roleOf(store, chatID); got != want {
+			t.Errorf("roleOf(%d) = %v, want %v", chatID, got, want)
+		}
+	}
+}
+
+func TestSetDetail(t *testing.T) {
+	store := newTestStore(t)
+	missions := []VBucksMission{{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"}}
+
+	tests := []struct {
+		args     string
+		wantLine string
+	}{
+		{args: "compact", wantLine: "1\\. *80 V\\-Bucks* — Twine Peaks"},
+		{args: "VERBOSE", wantLine: "1\\. PL 140 Ride the Lightning in Twine Peaks \\- *80 V\\-Bucks*"},
+	}
+	for _, tt := range tests {
+		setDetail(store, 1, tt.args)
+		text := formatMissionsForChat(store.get(1), formatOptions{}, missions)
+		if !strings.Contains(text, tt.wantLine) {
+			t.Errorf("after /detail %s the list is\n%s\nwant the line %s", tt.args, text, tt.wantLine)
+		}
+	}
+
+	if got := setDetail(store, 1, "medium"); !strings.HasPrefix(got, "Usage:") {
+		t.Errorf("/detail medium = %q, want the usage", got)
+	}
+}
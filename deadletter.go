@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxDeadLetterBytes caps the dead-letter file; it's rotated to
+	// deadLetterFile + ".1" once it grows past this
+	maxDeadLetterBytes = 1 << 20
+	// deadLettersShown is how many recent failures /deadletters lists
+	deadLettersShown = 10
+)
+
+// deadLetter is a message that could not be delivered
+type deadLetter struct {
+	ChatID int64
+	Time   time.Time
+	Error  string
+	Text   string
+}
+
+// deadLetterMu serializes writes to the dead-letter file
+var deadLetterMu sync.Mutex
+
+// isTransient reports whether a send error is worth retrying: network
+// failures, rate limiting and Telegram server errors
+func isTransient(err error) bool {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return true
+	}
+	return tgErr.Code == http.StatusTooManyRequests || tgErr.Code >= http.StatusInternalServerError
+}
+
+// recordDeadLetter appends an undeliverable message to the dead-letter file
+func recordDeadLetter(chatID int64, text string, sendErr error) {
+	log.Printf("Message to chat %d is undeliverable: %v", chatID, sendErr)
+
+	data, err := json.Marshal(deadLetter{
+		ChatID: chatID,
+		Time:   time.Now().UTC(),
+		Error:  sendErr.Error(),
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Error encoding dead letter: %v", err)
+		return
+	}
+
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	// Rotate instead of growing without bound
+	if info, err := os.Stat(deadLetterFile); err == nil && info.Size() > maxDeadLetterBytes {
+		if err := os.Rename(deadLetterFile, deadLetterFile+".1"); err != nil {
+			log.Printf("Error rotating dead-letter file: %v", err)
+		}
+	}
+
+	f, err := os.OpenFile(deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Error opening dead-letter file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("Error writing dead-letter file: %v", err)
+	}
+}
+
+// recentDeadLetters returns up to n of the most recent dead letters
+func recentDeadLetters(n int) ([]deadLetter, error) {
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	f, err := os.Open(deadLetterFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var letters []deadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxDeadLetterBytes)
+	for scanner.Scan() {
+		var letter deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			continue
+		}
+		letters = append(letters, letter)
+		if len(letters) > n {
+			letters = letters[1:]
+		}
+	}
+
+	return letters, scanner.Err()
+}
+
+// formatDeadLetters summarizes the recent delivery failures for /deadletters
+func formatDeadLetters() string {
+	letters, err := recentDeadLetters(deadLettersShown)
+	if err != nil {
+		return fmt.Sprintf("Couldn't read the dead-letter file: %v", err)
+	}
+	if len(letters) == 0 {
+		return "No undeliverable messages recorded."
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Last %d undeliverable messages:\n", len(letters)))
+	for _, letter := range letters {
+		preview := letter.Text
+		if runes := []rune(preview); len(runes) > 40 {
+			preview = string(runes[:40]) + "…"
+		}
+		result.WriteString(fmt.Sprintf("\n%s chat %s: %s\n%q\n",
+			letter.Time.Format(time.RFC3339), chatLabels.name(letter.ChatID), letter.Error, preview))
+	}
+
+	return result.String()
+}
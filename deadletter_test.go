@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSendRecordsPermanentFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		desc       string
+		wantLetter bool
+	}{
+		{name: "bad request", code: http.StatusBadRequest, desc: "Bad Request: chat not found", wantLetter: true},
+		{name: "server error", code: http.StatusInternalServerError, desc: "Internal Server Error"},
+		{name: "rate limited", code: http.StatusTooManyRequests, desc: "Too Many Requests: retry after 5"},
+		{name: "blocked", code: http.StatusForbidden, desc: "Forbidden: bot was blocked by the user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			fake, bot := newFakeTelegram(t)
+			fake.setFail(func(telegramRequest) (int, string) { return tt.code, tt.desc })
+
+			if err := send(bot, tgbotapi.NewMessage(42, "80 V-Bucks in Twine Peaks")); err == nil {
+				t.Fatal("send succeeded against a failing server")
+			}
+
+			letters, err := recentDeadLetters(deadLettersShown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantLetter {
+				if len(letters) != 0 {
+					t.Errorf("recorded %+v, want no dead letter for a %d", letters, tt.code)
+				}
+				return
+			}
+			if len(letters) != 1 {
+				t.Fatalf("recorded %d dead letters, want 1", len(letters))
+			}
+			if l := letters[0]; l.ChatID != 42 || l.Text != "80 V-Bucks in Twine Peaks" || l.Error != tt.desc {
+				t.Errorf("dead letter = %+v", l)
+			}
+		})
+	}
+}
+
+func TestRecentDeadLetters(t *testing.T) {
+	inTempDir(t)
+	if letters, err := recentDeadLetters(3); err != nil || letters != nil {
+		t.Fatalf("recentDeadLetters without a file = %v, %v, want nothing", letters, err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		recordDeadLetter(int64(i), fmt.Sprintf("message %d", i), errors.New("Bad Request"))
+	}
+	letters, err := recentDeadLetters(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 3 {
+		t.Fatalf("recentDeadLetters(3) returned %d letters", len(letters))
+	}
+	for i, l := range letters {
+		if want := int64(i + 3); l.ChatID != want {
+			t.Errorf("letter %d is for chat %d, want %d", i, l.ChatID, want)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: errors.New("connection reset by peer"), want: true},
+		{err: &tgbotapi.Error{Code: http.StatusTooManyRequests}, want: true},
+		{err: &tgbotapi.Error{Code: http.StatusBadGateway}, want: true},
+		{err: &tgbotapi.Error{Code: http.StatusBadRequest}, want: false},
+		{err: &tgbotapi.Error{Code: http.StatusForbidden}, want: false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// key identifies a mission by all of its fields
+func (m VBucksMission) key() string {
+	return strings.Join([]string{m.Area, m.MissionType, m.PowerLevel, m.Amount}, "|")
+}
+
+// diffMissions returns the missions in current but not in previous (added)
+// and the ones in previous but not in current (removed)
+func diffMissions(previous, current []VBucksMission) (added, removed []VBucksMission) {
+	// Count occurrences so repeated identical missions are diffed correctly
+	counts := map[string]int{}
+	for _, mission := range previous {
+		counts[mission.key()]++
+	}
+
+	for _, mission := range current {
+		if counts[mission.key()] > 0 {
+			counts[mission.key()]--
+			continue
+		}
+		added = append(added, mission)
+	}
+
+	for _, mission := range previous {
+		if counts[mission.key()] > 0 {
+			counts[mission.key()]--
+			removed = append(removed, mission)
+		}
+	}
+
+	return added, removed
+}
+
+// place identifies a recurring mission by its type and area, so the same
+// mission can be found on another day even when its reward changed
+func (m VBucksMission) place() string {
+	return strings.ToLower(m.MissionType + "|" + m.Area)
+}
+
+// amountChange is a mission whose reward differs from the day before
+type amountChange struct {
+	Mission VBucksMission
+	Before  int
+}
+
+// amountChanges pairs the missions in current with the ones in previous at
+// the same place, in order, and returns the pairs whose amounts differ
+func amountChanges(previous, current []VBucksMission) []amountChange {
+	byPlace := map[string][]VBucksMission{}
+	for _, mission := range previous {
+		byPlace[mission.place()] = append(byPlace[mission.place()], mission)
+	}
+
+	var changes []amountChange
+	for _, mission := range current {
+		candidates := byPlace[mission.place()]
+		if len(candidates) == 0 {
+			continue
+		}
+		before := candidates[0]
+		byPlace[mission.place()] = candidates[1:]
+
+		if missionAmount(before) != missionAmount(mission) {
+			changes = append(changes, amountChange{Mission: mission, Before: missionAmount(before)})
+		}
+	}
+	return changes
+}
+
+// formatAmountChanges handles /amountchanges: today's missions whose reward
+// changed since yesterday, with the difference
+func formatAmountChanges(today []VBucksMission, now time.Time) string {
+	date := now.UTC().AddDate(0, 0, -1).Format(dateLayout)
+	yesterday, ok := history.missionsOn(date)
+	if !ok {
+		return fmt.Sprintf("No missions were recorded yesterday (%s), so there's nothing to compare with.", date)
+	}
+
+	changes := amountChanges(yesterday, today)
+	if len(changes) == 0 {
+		return "No amount changes since yesterday."
+	}
+
+	var result strings.Builder
+	result.WriteString("Rewards changed since yesterday:\n")
+	for _, c := range changes {
+		after := missionAmount(c.Mission)
+		result.WriteString(fmt.Sprintf("\n%s in %s: %d → %d V-Bucks (%+d)", c.Mission.MissionType, c.Mission.Area, c.Before, after, after-c.Before))
+	}
+	return result.String()
+}
+
+// missionsHash fingerprints a day's missions regardless of their order, so
+// two days can be compared cheaply
+func missionsHash(vbucksMissions []VBucksMission) string {
+	keys := make([]string, 0, len(vbucksMissions))
+	for _, mission := range vbucksMissions {
+		keys = append(keys, mission.key())
+	}
+	sort.Strings(keys)
+
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// isRepeatDay reports whether the missions are the same as the ones recorded
+// the day before t
+func isRepeatDay(vbucksMissions []VBucksMission, t time.Time) bool {
+	if len(vbucksMissions) == 0 {
+		return false
+	}
+	yesterday, ok := history.missionsOn(t.UTC().AddDate(0, 0, -1).Format(dateLayout))
+	return ok && missionsHash(yesterday) == missionsHash(vbucksMissions)
+}
+
+// missionLine renders a mission as a single plain text line
+func missionLine(m VBucksMission) string {
+	return fmt.Sprintf("PL %s %s in %s - %s V-Bucks", m.PowerLevel, m.MissionType, m.Area, m.Amount)
+}
+
+// formatComparison describes how today's missions differ from a past date's
+func formatComparison(date string, past, today []VBucksMission) string {
+	added, removed := diffMissions(past, today)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Today vs %s:\n", date))
+
+	if len(added) == 0 && len(removed) == 0 {
+		result.WriteString("Same missions on both days.\n")
+	}
+	if len(added) > 0 {
+		result.WriteString("\nNew today:\n")
+		for _, mission := range added {
+			result.WriteString("+ " + missionLine(mission) + "\n")
+		}
+	}
+	if len(removed) > 0 {
+		result.WriteString(fmt.Sprintf("\nOnly on %s:\n", date))
+		for _, mission := range removed {
+			result.WriteString("- " + missionLine(mission) + "\n")
+		}
+	}
+
+	todayTotal, pastTotal := totalVBucks(today), totalVBucks(past)
+	result.WriteString(fmt.Sprintf("\nTotal: %d V-Bucks today vs %d (%+d)", todayTotal, pastTotal, todayTotal-pastTotal))
+
+	return result.String()
+}
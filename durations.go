@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultMissionMinutes is a rough guess of how long each mission type takes
+// a typical public squad, keyed by lowercase type (matched like /watchtype)
+var defaultMissionMinutes = map[string]int{
+	"ride the lightning":      15,
+	"retrieve the data":       10,
+	"rescue the survivors":    15,
+	"fight the storm":         20,
+	"repair the shelter":      15,
+	"evacuate the shelter":    15,
+	"deliver the bomb":        20,
+	"build the radar grid":    15,
+	"destroy the encampments": 15,
+	"launch the rocket":       25,
+	"eliminate and collect":   12,
+	"resupply":                12,
+}
+
+// missionMinutes is the duration table in use
+var missionMinutes = defaultMissionMinutes
+
+// loadMissionTimes merges MISSION_TIMES ("ride the lightning=12,deliver the
+// bomb=25", in minutes) over the default durations
+func loadMissionTimes() error {
+	minutes := map[string]int{}
+	for missionType, m := range defaultMissionMinutes {
+		minutes[missionType] = m
+	}
+
+	if v := os.Getenv("MISSION_TIMES"); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			var m int
+			var err error
+			if len(parts) == 2 {
+				m, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+			}
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || err != nil || m <= 0 {
+				return fmt.Errorf("invalid MISSION_TIMES entry %q, expected mission type=minutes", pair)
+			}
+			minutes[strings.ToLower(strings.TrimSpace(parts[0]))] = m
+		}
+	}
+
+	missionMinutes = minutes
+	return nil
+}
+
+// estimatedMinutes looks up how long a mission type takes, preferring the
+// longest matching table entry so specific overrides win
+func estimatedMinutes(missionType string) (int, bool) {
+	configMu.RLock()
+	table := missionMinutes
+	configMu.RUnlock()
+
+	best, bestLen := 0, 0
+	for name, m := range table {
+		if matchesType(missionType, name) && len(name) > bestLen {
+			best, bestLen = m, len(name)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// vbucksPerMinute returns the mission's V-Bucks per estimated minute, for
+// ranking with rankMissions
+func vbucksPerMinute(m VBucksMission) (float64, bool) {
+	amount, ok := m.amountValue()
+	if !ok {
+		return 0, false
+	}
+	minutes, ok := estimatedMinutes(m.MissionType)
+	if !ok {
+		return 0, false
+	}
+	return float64(amount) / float64(minutes), true
+}
+
+// formatMissionTimes lists missions by V-Bucks per minute for /time
+func formatMissionTimes(vbucksMissions []VBucksMission) string {
+	if len(vbucksMissions) == 0 {
+		return "No V-Bucks missions found today."
+	}
+
+	var result strings.Builder
+	result.WriteString("Missions by V-Bucks per minute:\n\n")
+	for i, r := range rankMissions(vbucksMissions, vbucksPerMinute) {
+		estimate := "unknown"
+		if minutes, ok := estimatedMinutes(r.mission.MissionType); ok {
+			estimate = fmt.Sprintf("~%d min", minutes)
+		}
+		rate := "n/a"
+		if r.scored {
+			rate = fmt.Sprintf("%.1f/min", r.score)
+		}
+		result.WriteString(fmt.Sprintf("%d. %s, %s - %s\n", i+1, estimate, rate, missionLine(r.mission)))
+	}
+	result.WriteString("\nTimes are rough estimates for a public squad.")
+
+	return result.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// rankedMission is a mission with the score it was ranked by
+type rankedMission struct {
+	mission VBucksMission
+	score   float64
+	// scored is false when the score couldn't be computed, e.g. no power level
+	scored bool
+}
+
+// efficiency returns V-Bucks per power level, using the top of the power level
+// range as the mission's difficulty
+func efficiency(m VBucksMission) (float64, bool) {
+	amount, ok := m.amountValue()
+	if !ok {
+		return 0, false
+	}
+	_, level, ok := powerRange(m.PowerLevel)
+	if !ok || level <= 0 {
+		return 0, false
+	}
+	return float64(amount) / float64(level), true
+}
+
+// rankMissions sorts missions by score, highest first, with unscored missions last
+func rankMissions(vbucksMissions []VBucksMission, score func(VBucksMission) (float64, bool)) []rankedMission {
+	ranked := make([]rankedMission, 0, len(vbucksMissions))
+	for _, mission := range vbucksMissions {
+		value, ok := score(mission)
+		ranked = append(ranked, rankedMission{mission: mission, score: value, scored: ok})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].scored != ranked[j].scored {
+			return ranked[i].scored
+		}
+		return ranked[i].score > ranked[j].score
+	})
+
+	return ranked
+}
+
+// formatEfficientMissions lists missions by V-Bucks per power level for /efficient
+func formatEfficientMissions(vbucksMissions []VBucksMission) string {
+	if len(vbucksMissions) == 0 {
+		return "No V-Bucks missions found today."
+	}
+
+	var result strings.Builder
+	result.WriteString("Missions by V-Bucks per power level:\n\n")
+	for i, r := range rankMissions(vbucksMissions, efficiency) {
+		ratio := "n/a"
+		if r.scored {
+			ratio = fmt.Sprintf("%.2f", r.score)
+		}
+		result.WriteString(fmt.Sprintf("%d. %s per PL - %s\n", i+1, ratio, missionLine(r.mission)))
+	}
+
+	return strings.TrimSuffix(result.String(), "\n")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// toUTF8 makes sure a scraped page is valid UTF-8 before it's parsed
+// Pages in another encoding are transcoded, using the Content-Type header or
+// the page's meta tags to tell which, and any bytes still invalid afterwards
+// are dropped so the parser never sees broken runes
+func toUTF8(name string, body []byte, contentType string) []byte {
+	if utf8.Valid(body) {
+		return body
+	}
+
+	enc, encName, _ := charset.DetermineEncoding(body, contentType)
+	if encName != "utf-8" {
+		if decoded, err := enc.NewDecoder().Bytes(body); err == nil {
+			log.Printf("%s served %s, transcoded it to UTF-8", name, encName)
+			body = decoded
+		} else {
+			log.Printf("Error transcoding %s from %s: %v", name, encName, err)
+		}
+	}
+
+	if !utf8.Valid(body) {
+		log.Printf("%s served invalid UTF-8, dropping the invalid bytes", name)
+		body = bytes.ToValidUTF8(body, nil)
+	}
+	return body
+}
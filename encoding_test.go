@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// latin1 encodes an ASCII-and-Latin-1 string as ISO-8859-1 bytes
+func latin1(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		b = append(b, byte(r))
+	}
+	return b
+}
+
+func TestToUTF8Latin1(t *testing.T) {
+	mission := "80 140Défendre la Tempête in Twine Peaks"
+	tests := []struct {
+		name        string
+		page        []byte
+		contentType string
+	}{
+		{name: "meta tag", page: latin1(`<html><head><meta charset="iso-8859-1"></head><body><div class="news-link"><div class="infonotice">` + mission + `</div></div></body></html>`)},
+		{name: "content type", page: latin1(`<div class="news-link"><div class="infonotice">` + mission + `</div></div>`), contentType: "text/html; charset=ISO-8859-1"},
+	}
+	for _, tt := range tests {
+		body := toUTF8("test", tt.page, tt.contentType)
+		if !utf8.Valid(body) {
+			t.Fatalf("%s: toUTF8 returned invalid UTF-8", tt.name)
+		}
+
+		missions, err := parseMissionsHTML(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missions) != 1 || missions[0].MissionType != "Défendre la Tempête" || missions[0].Area != "Twine Peaks" {
+			t.Errorf("%s: parsed %+v, want the mission type with its accents", tt.name, missions)
+		}
+	}
+}
+
+func TestToUTF8DropsInvalidBytes(t *testing.T) {
+	page := append([]byte(`<meta charset="utf-8">80 140Ride the Lightning`), 0xff, 0xfe)
+	page = append(page, []byte(" in Twine Peaks")...)
+
+	body := toUTF8("test", page, "text/html; charset=utf-8")
+	if !utf8.Valid(body) {
+		t.Fatalf("toUTF8 kept invalid bytes: %q", body)
+	}
+	if want := `<meta charset="utf-8">80 140Ride the Lightning in Twine Peaks`; string(body) != want {
+		t.Errorf("toUTF8 = %q, want %q", body, want)
+	}
+}
+
+func TestToUTF8LeavesValidPages(t *testing.T) {
+	page := []byte("80 140Défendre in Twine Peaks")
+	if got := toUTF8("test", page, "text/html; charset=ISO-8859-1"); string(got) != string(page) {
+		t.Errorf("toUTF8 changed a valid UTF-8 page to %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maintenanceMarkers identify maintenance and error pages some hosts serve
+// with a 200 status, matched case-insensitively
+var maintenanceMarkers = []string{
+	// WordPress while it's updating
+	"briefly unavailable for scheduled maintenance",
+	"<title>maintenance",
+	"site is under maintenance",
+	"we'll be back soon",
+	"<title>503 service",
+	"<title>502 bad gateway",
+}
+
+// checkSourcePage rejects responses that aren't the real page: any status
+// other than 200, and maintenance pages served as 200, so they're reported as
+// a failed fetch instead of being parsed and cached
+func checkSourcePage(status int, body []byte) error {
+	if status != 0 && status != http.StatusOK {
+		return fmt.Errorf("source answered HTTP %d %s", status, http.StatusText(status))
+	}
+
+	page := strings.ToLower(string(body))
+	for _, marker := range maintenanceMarkers {
+		if strings.Contains(page, marker) {
+			return fmt.Errorf("source served a maintenance page (matched %q)", marker)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchHTMLMissionsRejectsErrorPages(t *testing.T) {
+	page, err := ioutil.ReadFile("testdata/timed-missions.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "service unavailable", status: http.StatusServiceUnavailable, body: "<html><body>Service Unavailable</body></html>", wantErr: "HTTP 503"},
+		{name: "maintenance served as 200", status: http.StatusOK, body: "<html><title>Maintenance</title><body>Briefly unavailable for scheduled maintenance. Check back in a minute.</body></html>", wantErr: "maintenance page"},
+		{name: "bad gateway page served as 200", status: http.StatusOK, body: "<html><title>502 Bad Gateway</title></html>", wantErr: "maintenance page"},
+		{name: "real page", status: http.StatusOK, body: string(page)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The fetched page is saved for debugging
+			inTempDir(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			missions, err := fetchHTMLMissions(Source{Name: "test", Kind: SourceKindHTML, URL: srv.URL})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(missions) != 3 {
+					t.Errorf("parsed %d missions from the real page, want 3", len(missions))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("fetchHTMLMissions error = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if missions != nil {
+				t.Errorf("fetchHTMLMissions parsed %+v from an error page", missions)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultFallbackMessage is what commands answer with when there is no
+// mission data at all to show
+const defaultFallbackMessage = "Mission data is temporarily unavailable, the source couldn't be reached. Please try again later."
+
+// fallbackMessage is set with FALLBACK_MESSAGE
+var fallbackMessage = defaultFallbackMessage
+
+// loadFallbackMessage reads FALLBACK_MESSAGE, keeping the default when it's empty
+func loadFallbackMessage() {
+	// .env values can't hold real newlines, so accept \n escapes
+	text := strings.TrimSpace(strings.ReplaceAll(os.Getenv("FALLBACK_MESSAGE"), `\n`, "\n"))
+	if text == "" {
+		text = defaultFallbackMessage
+	}
+	fallbackMessage = text
+}
+
+// unavailable reports whether fetching failed with no cache to fall back
+// on, so there's no data at all rather than a day without missions
+func (f Freshness) unavailable() bool {
+	return f.Stale && f.UpdatedAt.IsZero()
+}
+
+// fallbackText is the plain-text reply for when there's no data, with a
+// link to the source so users can check it themselves
+func fallbackText() string {
+	configMu.RLock()
+	text, srcs := fallbackMessage, sources
+	configMu.RUnlock()
+
+	if len(srcs) > 0 && srcs[0].URL != "" {
+		text += "\n\nYou can check the missions yourself at " + srcs[0].URL
+	}
+	return text
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// matchesArea reports whether the area fuzzy-matches the filter, ignoring case
+// and allowing partial names such as "twine" for "Twine Peaks"
+func matchesArea(area, filter string) bool {
+	return strings.Contains(strings.ToLower(area), strings.ToLower(strings.TrimSpace(filter)))
+}
+
+// filterByArea returns only the missions whose area matches the filter
+func filterByArea(vbucksMissions []VBucksMission, filter string) []VBucksMission {
+	var filtered []VBucksMission
+	for _, mission := range vbucksMissions {
+		if matchesArea(mission.Area, filter) {
+			filtered = append(filtered, mission)
+		}
+	}
+	return filtered
+}
+
+// missionAreas lists the distinct areas of the missions in the order they appear
+func missionAreas(vbucksMissions []VBucksMission) []string {
+	var areas []string
+	seen := map[string]bool{}
+	for _, mission := range vbucksMissions {
+		if !seen[mission.Area] {
+			seen[mission.Area] = true
+			areas = append(areas, mission.Area)
+		}
+	}
+	return areas
+}
+
+// powerRange parses a power level such as "76" or a range such as "76-82"
+// A single level is treated as a range with equal bounds
+func powerRange(level string) (min, max int, ok bool) {
+	parts := strings.SplitN(strings.TrimSpace(level), "-", 2)
+
+	min, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	max = min
+
+	if len(parts) == 2 {
+		max, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || max < min {
+			return 0, 0, false
+		}
+	}
+
+	return min, max, true
+}
+
+// powerLevelAtLeast reports whether a mission passes a "pl>=x" filter, which
+// is the case when the top of its power level range reaches x
+func powerLevelAtLeast(m VBucksMission, x int) bool {
+	_, max, ok := powerRange(m.PowerLevel)
+	return ok && max >= x
+}
+
+// powerLevelAtMost reports whether a mission passes a "pl<=x" filter, which
+// is the case when the bottom of its power level range is at most x
+func powerLevelAtMost(m VBucksMission, x int) bool {
+	min, _, ok := powerRange(m.PowerLevel)
+	return ok && min <= x
+}
+
+// filterByPowerLevel returns the missions passing "pl>=minLevel" and
+// "pl<=maxLevel"; a zero bound is not applied
+func filterByPowerLevel(vbucksMissions []VBucksMission, minLevel, maxLevel int) []VBucksMission {
+	var filtered []VBucksMission
+	for _, mission := range vbucksMissions {
+		if minLevel > 0 && !powerLevelAtLeast(mission, minLevel) {
+			continue
+		}
+		if maxLevel > 0 && !powerLevelAtMost(mission, maxLevel) {
+			continue
+		}
+		filtered = append(filtered, mission)
+	}
+	return filtered
+}
+
+// filterForChat applies the chat's area and power level filters
+func filterForChat(prefs ChatPreferences, vbucksMissions []VBucksMission) []VBucksMission {
+	if prefs.Area != "" {
+		vbucksMissions = filterByArea(vbucksMissions, prefs.Area)
+	}
+	if prefs.MinPowerLevel > 0 || prefs.MaxPowerLevel > 0 {
+		vbucksMissions = filterByPowerLevel(vbucksMissions, prefs.MinPowerLevel, prefs.MaxPowerLevel)
+	}
+	return vbucksMissions
+}
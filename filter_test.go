@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPowerRange(t *testing.T) {
+	tests := []struct {
+		level    string
+		min, max int
+		ok       bool
+	}{
+		{"140", 140, 140, true},
+		{"76-82", 76, 82, true},
+		{" 76 - 82 ", 76, 82, true},
+		{"82-76", 0, 0, false},
+		{"high", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		min, max, ok := powerRange(tt.level)
+		if min != tt.min || max != tt.max || ok != tt.ok {
+			t.Errorf("powerRange(%q) = %d, %d, %v, want %d, %d, %v", tt.level, min, max, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestPowerLevelFiltersStraddlingRanges(t *testing.T) {
+	// 76-82 straddles both 80 thresholds, so it passes pl>=80 and pl<=80
+	straddling := VBucksMission{PowerLevel: "76-82"}
+	tests := []struct {
+		name    string
+		pass    func(VBucksMission, int) bool
+		mission VBucksMission
+		x       int
+		want    bool
+	}{
+		{"at least, top reaches it", powerLevelAtLeast, straddling, 80, true},
+		{"at least, top is it", powerLevelAtLeast, straddling, 82, true},
+		{"at least, range below", powerLevelAtLeast, straddling, 83, false},
+		{"at most, bottom below it", powerLevelAtMost, straddling, 80, true},
+		{"at most, bottom is it", powerLevelAtMost, straddling, 76, true},
+		{"at most, range above", powerLevelAtMost, straddling, 75, false},
+		{"unparsed level fails", powerLevelAtLeast, VBucksMission{PowerLevel: "?"}, 1, false},
+	}
+	for _, tt := range tests {
+		if got := tt.pass(tt.mission, tt.x); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByPowerLevel(t *testing.T) {
+	missions := []VBucksMission{
+		{PowerLevel: "40", Area: "Plankerton"},
+		{PowerLevel: "76-82", Area: "Canny Valley"},
+		{PowerLevel: "140", Area: "Twine Peaks"},
+	}
+	tests := []struct {
+		name     string
+		min, max int
+		want     []string
+	}{
+		{"no bounds", 0, 0, []string{"Plankerton", "Canny Valley", "Twine Peaks"}},
+		{"at least 80", 80, 0, []string{"Canny Valley", "Twine Peaks"}},
+		{"at most 80", 0, 80, []string{"Plankerton", "Canny Valley"}},
+		{"80 to 80", 80, 80, []string{"Canny Valley"}},
+	}
+	for _, tt := range tests {
+		var areas []string
+		for _, mission := range filterByPowerLevel(missions, tt.min, tt.max) {
+			areas = append(areas, mission.Area)
+		}
+		if !reflect.DeepEqual(areas, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, areas, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// fixtureMissions are served instead of scraping when FIXTURE_PATH is set
+// fixtureMode tells an empty fixture apart from no fixture at all
+var (
+	fixtureMissions []VBucksMission
+	fixtureMode     bool
+)
+
+// loadFixture reads the canned missions named by FIXTURE_PATH, if set
+func loadFixture() error {
+	path := os.Getenv("FIXTURE_PATH")
+	if path == "" {
+		fixtureMissions, fixtureMode = nil, false
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture: %v", err)
+	}
+
+	missions, err := parseFixture(data)
+	if err != nil {
+		return fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+
+	fixtureMissions = missions
+	fixtureMode = true
+	return nil
+}
+
+// parseFixture decodes a JSON array of missions, rejecting unknown fields and
+// missions missing the values the formatter relies on
+func parseFixture(data []byte) ([]VBucksMission, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var missions []VBucksMission
+	if err := dec.Decode(&missions); err != nil {
+		return nil, err
+	}
+
+	for i, mission := range missions {
+		amount, err := parseAmount(mission.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("mission %d: %v", i+1, err)
+		}
+		missions[i].Amount = strconv.Itoa(amount)
+		// Same area names as the live parsers give
+		missions[i].Area = normalizeArea(mission.Area)
+		if missions[i].Area == "" || mission.MissionType == "" {
+			return nil, fmt.Errorf("mission %d: area and mission type are required", i+1)
+		}
+	}
+
+	return missions, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestParseFixtureNormalizesAmounts(t *testing.T) {
+	data := `[
+		{"Amount": "1,000", "PowerLevel": "76-82", "MissionType": "Fight the Storm", "Area": "Canny Valley"},
+		{"Amount": "80 V-Bucks", "PowerLevel": "140", "MissionType": "Ride the Lightning", "Area": "Twine Peaks"}
+	]`
+	missions, err := parseFixture([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missions[0].Amount != "1000" || missions[1].Amount != "80" {
+		t.Errorf("amounts = %q, %q, want 1000, 80", missions[0].Amount, missions[1].Amount)
+	}
+}
+
+func TestParseFixtureRejectsBadAmount(t *testing.T) {
+	data := `[{"Amount": "lots", "PowerLevel": "140", "MissionType": "Ride the Lightning", "Area": "Twine Peaks"}]`
+	if _, err := parseFixture([]byte(data)); err == nil {
+		t.Error("parseFixture accepted the amount \"lots\"")
+	}
+}
+
+func TestParseFixtureNormalizesAreas(t *testing.T) {
+	data := `[
+		{"Amount": "80", "PowerLevel": "140", "MissionType": "Ride the Lightning", "Area": "twine  PEAKS"},
+		{"Amount": "50", "PowerLevel": "76-82", "MissionType": "Fight the Storm", "Area": "cv"},
+		{"Amount": "40", "PowerLevel": "64", "MissionType": "Retrieve the Data", "Area": " Plankerton "}
+	]`
+	missions, err := parseFixture([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"Twine Peaks", "Canny Valley", "Plankerton"} {
+		if missions[i].Area != want {
+			t.Errorf("mission %d area = %q, want %q", i+1, missions[i].Area, want)
+		}
+	}
+
+	blank := `[{"Amount": "80", "PowerLevel": "140", "MissionType": "Ride the Lightning", "Area": "   "}]`
+	if _, err := parseFixture([]byte(blank)); err == nil {
+		t.Error("parseFixture accepted a blank area")
+	}
+}
+
+// withFixture serves missions from getMissions for the test, as FIXTURE_PATH
+// would, so nothing is scraped
+func withFixture(t *testing.T, missions []VBucksMission) {
+	t.Helper()
+	savedMissions, savedMode := fixtureMissions, fixtureMode
+	fixtureMissions, fixtureMode = missions, true
+	t.Cleanup(func() { fixtureMissions, fixtureMode = savedMissions, savedMode })
+}
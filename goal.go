@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// goalDonePrefix starts the callback data of the /progress "done" buttons
+const goalDonePrefix = "done:"
+
+// goalMissionID identifies a day's mission in callback data, which Telegram
+// limits to 64 bytes
+func goalMissionID(date string, m VBucksMission) string {
+	sum := sha256.Sum256([]byte(date + "|" + m.key()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// setGoal handles /goal: "<amount>" sets a new goal, "off" clears it and no
+// arguments shows the current one
+func setGoal(store *preferenceStore, chatID int64, args string) string {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	prefs := store.get(chatID)
+
+	switch arg {
+	case "":
+		if prefs.Goal == 0 {
+			return "You have no V-Bucks goal. Set one with /goal <amount>, e.g. /goal 1000"
+		}
+		return fmt.Sprintf("Your goal is %d V-Bucks, %d earned so far. See /progress", prefs.Goal, prefs.GoalEarned)
+	case "off":
+		if err := store.update(chatID, func(p *ChatPreferences) {
+			p.Goal, p.GoalEarned, p.GoalDone = 0, 0, nil
+		}); err != nil {
+			log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+			return "Sorry, your goal couldn't be cleared. Please try again later."
+		}
+		return "Goal cleared."
+	}
+
+	goal, err := strconv.Atoi(arg)
+	if err != nil || goal <= 0 || goal > 1000000 {
+		return "Usage: /goal <amount> with a positive number of V-Bucks, or /goal off"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) {
+		p.Goal, p.GoalEarned, p.GoalDone = goal, 0, nil
+	}); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your goal couldn't be saved. Please try again later."
+	}
+	return fmt.Sprintf("Goal set to %d V-Bucks. Use /progress to track it and mark missions done.", goal)
+}
+
+// plural renders a count with its noun, e.g. "1 day" or "3 days"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// dailyAverage is the average V-Bucks offered per recorded day of history
+func dailyAverage(h *historyStore) (float64, int) {
+	dates := h.dates()
+	if len(dates) == 0 {
+		return 0, 0
+	}
+
+	total := 0
+	for _, date := range dates {
+		missions, _ := h.missionsOn(date)
+		total += totalVBucks(missions)
+	}
+	return float64(total) / float64(len(dates)), len(dates)
+}
+
+// formatProgress builds the /progress reply: how far the chat is from its goal,
+// an estimate of the days left, and a button per mission of today not yet done
+func formatProgress(prefs ChatPreferences, vbucksMissions []VBucksMission, now time.Time) (string, *tgbotapi.InlineKeyboardMarkup) {
+	if prefs.Goal == 0 {
+		return "You have no V-Bucks goal. Set one with /goal <amount>, e.g. /goal 1000", nil
+	}
+
+	remaining := prefs.Goal - prefs.GoalEarned
+	if remaining <= 0 {
+		return fmt.Sprintf("🎉 Goal reached: %d of %d V-Bucks! Set a new one with /goal <amount>.", prefs.GoalEarned, prefs.Goal), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Goal: %d of %d V-Bucks, %d to go\n", prefs.GoalEarned, prefs.Goal, remaining))
+
+	if average, days := dailyAverage(history); average > 0 {
+		result.WriteString(fmt.Sprintf("At the average of %.0f V-Bucks a day (over %s), that's about %s more.\n",
+			average, plural(days, "day"), plural(int(math.Ceil(float64(remaining)/average)), "day")))
+	} else {
+		result.WriteString("Not enough history yet to estimate how long that takes.\n")
+	}
+
+	today := now.UTC().Format(dateLayout)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, mission := range filterForChat(prefs, vbucksMissions) {
+		amount, ok := mission.amountValue()
+		if !ok {
+			continue
+		}
+		id := goalMissionID(today, mission)
+		if _, done := prefs.GoalDone[id]; done {
+			continue
+		}
+		label := fmt.Sprintf("✅ %d V-Bucks: %s", amount, mission.MissionType)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, goalDonePrefix+id)))
+	}
+
+	if len(rows) == 0 {
+		result.WriteString("\nNo missions left to mark done today.")
+		return result.String(), nil
+	}
+
+	result.WriteString("\nTap a mission once you've done it:")
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return result.String(), &keyboard
+}
+
+// sendProgress handles /progress
+func sendProgress(t *tenant, chatID int64) {
+	missions, _ := getMissions()
+	text, keyboard := formatProgress(t.prefs.get(chatID), missions, time.Now())
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+	send(t.bot, msg)
+}
+
+// markGoalMissionDone credits a mission of today to the chat's goal and
+// returns the updated progress, ignoring missions already marked done
+func markGoalMissionDone(t *tenant, chatID int64, id string) (string, *tgbotapi.InlineKeyboardMarkup) {
+	now := time.Now()
+	today := now.UTC().Format(dateLayout)
+	oldest := history.oldestDate(now)
+	missions, _ := getMissions()
+
+	err := t.prefs.update(chatID, func(p *ChatPreferences) {
+		if _, done := p.GoalDone[id]; done || p.Goal == 0 {
+			return
+		}
+		for _, mission := range missions {
+			if goalMissionID(today, mission) != id {
+				continue
+			}
+			amount, _ := mission.amountValue()
+
+			if p.GoalDone == nil {
+				p.GoalDone = map[string]string{}
+			}
+			for doneID, date := range p.GoalDone {
+				if date < oldest {
+					delete(p.GoalDone, doneID)
+				}
+			}
+			p.GoalDone[id] = today
+			p.GoalEarned += amount
+			return
+		}
+	})
+	if err != nil {
+		log.Printf("Error saving goal progress for chat %d: %v", chatID, err)
+	}
+
+	return formatProgress(t.prefs.get(chatID), missions, now)
+}
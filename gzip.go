@@ -0,0 +1,125 @@
+package main
+
+import (
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinBytes is the smallest response worth compressing; below it the
+// gzip overhead outweighs the savings
+const gzipMinBytes = 1024
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		fields := strings.Split(part, ";")
+		if strings.TrimSpace(fields[0]) != "gzip" {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses it
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter holds back the start of a response until it knows
+// whether it's big enough to compress
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    []byte
+	gz     *gzip.Writer
+	// passthrough is set for statuses that carry no body to compress, which
+	// are sent straight through
+	passthrough bool
+}
+
+// WriteHeader delays the status until the encoding is decided, except for
+// statuses without a body
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified || status < http.StatusOK {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write buffers until gzipMinBytes, then switches to compressing
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < gzipMinBytes {
+		return len(p), nil
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.statusCode())
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	if _, err := w.gz.Write(w.buf); err != nil {
+		return 0, err
+	}
+	w.buf = nil
+	return len(p), nil
+}
+
+// statusCode is the status to send, 200 if the handler never set one
+func (w *gzipResponseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// finish flushes the response once the handler returns
+func (w *gzipResponseWriter) finish() {
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			log.Printf("Error finishing gzip response: %v", err)
+		}
+		return
+	}
+	if w.passthrough {
+		return
+	}
+
+	// Too small to bother compressing
+	w.ResponseWriter.WriteHeader(w.statusCode())
+	if _, err := w.ResponseWriter.Write(w.buf); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// gzipHandler compresses responses of gzipMinBytes or more for clients that
+// accept gzip
+func gzipHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.finish()
+		next(gw, r)
+	}
+}
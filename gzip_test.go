@@ -0,0 +1,119 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// getWithEncoding requests the handler with the given Accept-Encoding and
+// returns the response and its decoded body
+func getWithEncoding(t *testing.T, h http.HandlerFunc, encoding string) (*http.Response, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/missions", nil)
+	if encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	resp := rec.Result()
+
+	body := resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = gz
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func TestGzipHandlerDecodesToSameJSON(t *testing.T) {
+	var missions []VBucksMission
+	for i := 0; i < 50; i++ {
+		missions = append(missions, VBucksMission{Area: "Twine Peaks", PowerLevel: "140", Amount: "80", MissionType: "Ride the Lightning"})
+	}
+	h := gzipHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(missions)
+	})
+
+	plainResp, plain := getWithEncoding(t, h, "")
+	gzResp, compressed := getWithEncoding(t, h, "gzip, deflate")
+
+	if got := plainResp.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding without Accept-Encoding = %q, want none", got)
+	}
+	if got := gzResp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("Content-Encoding with gzip accepted = %q, want gzip", got)
+	}
+
+	var fromPlain, fromGzip []VBucksMission
+	if err := json.Unmarshal(plain, &fromPlain); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(compressed, &fromGzip); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromPlain, fromGzip) || !reflect.DeepEqual(fromPlain, missions) {
+		t.Error("compressed and uncompressed responses decode to different missions")
+	}
+}
+
+func TestGzipHandlerSkipsSmallAndEmptyResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "small", status: http.StatusOK, body: `{"missions": []}`},
+		{name: "not modified", status: http.StatusNotModified},
+		{name: "error", status: http.StatusServiceUnavailable, body: "no data yet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := gzipHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			resp, body := getWithEncoding(t, h, "gzip")
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if strings.TrimSpace(string(body)) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip":     true,
+		"gzip;q=0.5":        true,
+		"gzip;q=0":          false,
+		"identity, deflate": false,
+	}
+	for header, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/missions", nil)
+		req.Header.Set("Accept-Encoding", header)
+		if got := acceptsGzip(req); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
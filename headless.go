@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// headlessTimeout bounds how long rendering a page in the browser may take,
+// including waiting for a challenge to clear
+const headlessTimeout = 60 * time.Second
+
+// headlessFallback renders pages in a headless browser when the source
+// answers with an anti-bot challenge; enabled with HEADLESS_FALLBACK=1
+// since it needs Chrome installed
+var headlessFallback bool
+
+// challengeMarkers appear in Cloudflare's JS challenge pages
+var challengeMarkers = []string{
+	"cf-browser-verification",
+	"cf-chl-",
+	"challenge-platform",
+	"<title>Just a moment...</title>",
+}
+
+// isChallenge reports whether a response is an anti-bot challenge page
+// rather than the real content
+func isChallenge(status int, header http.Header, body []byte) bool {
+	if header != nil && header.Get("Cf-Mitigated") == "challenge" {
+		return true
+	}
+	if status != http.StatusForbidden && status != http.StatusServiceUnavailable {
+		return false
+	}
+
+	page := string(body)
+	for _, marker := range challengeMarkers {
+		if strings.Contains(page, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderHeadless loads the page in headless Chrome, waits for the selector to
+// show up and returns the rendered HTML
+func renderHeadless(url, selector string) ([]byte, error) {
+	ctx, cancel := chromedp.NewContext(context.Background())
+	defer cancel()
+
+	ctx, cancel = context.WithTimeout(ctx, headlessTimeout)
+	defer cancel()
+
+	var html string
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(url),
+		chromedp.WaitReady(selector, chromedp.ByQuery),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(html), nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultHealthMaxStale is how old served data may get before /health turns red
+	defaultHealthMaxStale = 12 * time.Hour
+	// defaultHealthMaxFailures is how many failed scrapes in a row turn /health red
+	defaultHealthMaxFailures = 3
+)
+
+var (
+	// healthMaxStale and healthMaxFailures are the red thresholds for /health
+	healthMaxStale    = defaultHealthMaxStale
+	healthMaxFailures = defaultHealthMaxFailures
+)
+
+// loadHealthThresholds reads HEALTH_MAX_STALE and HEALTH_MAX_FAILURES
+func loadHealthThresholds() error {
+	maxStale, err := durationSetting("HEALTH_MAX_STALE", defaultHealthMaxStale)
+	if err != nil {
+		return err
+	}
+
+	maxFailures := defaultHealthMaxFailures
+	if v := os.Getenv("HEALTH_MAX_FAILURES"); v != "" {
+		maxFailures, err = strconv.Atoi(v)
+		if err != nil || maxFailures < 1 {
+			return fmt.Errorf("invalid HEALTH_MAX_FAILURES %q: must be a positive number", v)
+		}
+	}
+
+	healthMaxStale = maxStale
+	healthMaxFailures = maxFailures
+	return nil
+}
+
+// formatHealth rolls the fetch status up into a green, yellow or red line
+// for /health, followed by the reason
+func formatHealth(missions []VBucksMission, freshness Freshness, now time.Time) string {
+	configMu.RLock()
+	maxStale, maxFailures := healthMaxStale, healthMaxFailures
+	configMu.RUnlock()
+
+	failures := fetchStatus.ConsecutiveFailures()
+	broken := fetchStatus.LayoutChanged()
+	age := now.Sub(freshness.UpdatedAt)
+
+	suspect := 0
+	for _, mission := range missions {
+		if mission.Suspect {
+			suspect++
+		}
+	}
+
+	switch {
+	case freshness.UpdatedAt.IsZero() && len(missions) > 0:
+		// Only the fixture comes without a timestamp
+		return "🟢 Green: serving the configured fixture"
+	case freshness.UpdatedAt.IsZero():
+		return "🔴 Red: no mission data is available yet"
+	case failures >= maxFailures:
+		return fmt.Sprintf("🔴 Red: the last %d scrapes failed", failures)
+	case freshness.Stale && age > maxStale:
+		return fmt.Sprintf("🔴 Red: serving data from %s ago", formatAge(age))
+	case len(broken) > 0:
+		return fmt.Sprintf("🔴 Red: no missions container on %s, the source's markup may have changed", strings.Join(broken, ", "))
+	case freshness.Stale:
+		return fmt.Sprintf("🟡 Yellow: serving data from %s ago while the source is unavailable", formatAge(age))
+	case failures > 0:
+		return "🟡 Yellow: the last scrape failed, today's data is still fresh"
+	case suspect > 0:
+		return fmt.Sprintf("🟡 Yellow: %s couldn't be parsed cleanly, check them against the source", plural(suspect, "mission"))
+	case fetchStatus.Degraded():
+		return fmt.Sprintf("🟡 Yellow: the source is slow, the last scrape took %s", fetchStatus.LastLatency().Round(time.Second))
+	}
+
+	return fmt.Sprintf("🟢 Green: fresh data from %s ago", formatAge(age))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withFetchStatus gives the test a fresh fetch status
+func withFetchStatus(t *testing.T) *FetchStatus {
+	t.Helper()
+	saved := fetchStatus
+	fetchStatus = NewFetchStatus()
+	t.Cleanup(func() { fetchStatus = saved })
+	return fetchStatus
+}
+
+func TestFormatHealthBreakage(t *testing.T) {
+	status := withFetchStatus(t)
+	now := time.Now()
+	fresh := Freshness{UpdatedAt: now.Add(-time.Hour)}
+	suspect := []VBucksMission{
+		{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"},
+		{Amount: "50", PowerLevel: "?", MissionType: "Fight the Storm", Area: "Canny Valley", Suspect: true},
+	}
+
+	// One odd row is worth a look, not an alarm
+	if got := formatHealth(suspect, fresh, now); !strings.HasPrefix(got, "🟡 Yellow: 1 mission couldn't be parsed cleanly") {
+		t.Errorf("health with a suspect mission = %q, want yellow", got)
+	}
+
+	_, err := parseMissionsHTML([]byte(`<html><body><section class="missions"></section></body></html>`))
+	if !isLayoutChanged(err) {
+		t.Fatalf("parseMissionsHTML error = %v, want a layout change", err)
+	}
+	status.RecordSource("freethevbucks", 0, time.Second, err)
+	status.RecordSource("mirror", 2, time.Second, nil)
+	if got := formatHealth(testMissions, fresh, now); !strings.HasPrefix(got, "🔴 Red: no missions container on freethevbucks") {
+		t.Errorf("health with a missing container = %q, want red", got)
+	}
+
+	// Other failures don't count as breakage
+	status.RecordSource("freethevbucks", 0, time.Second, errors.New("connection refused"))
+	if got := formatHealth(testMissions, fresh, now); !strings.HasPrefix(got, "🟢 Green") {
+		t.Errorf("health after a plain source error = %q, want green", got)
+	}
+	if broken := status.LayoutChanged(); len(broken) != 0 {
+		t.Errorf("LayoutChanged = %q after a fetch that failed for another reason", broken)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// dateLayout is how days are written in history and command arguments
+	dateLayout = "2006-01-02"
+	// defaultHistoryDays is how many days of missions are kept by default
+	defaultHistoryDays = 30
+)
+
+// historyStore keeps each day's missions, keyed by UTC date, and persists
+// them to a file
+type historyStore struct {
+	mu        sync.Mutex
+	path      string
+	retention int
+	days      map[string][]VBucksMission
+}
+
+// history is shared by every bot since the missions are the same for all
+var history = &historyStore{path: historyFile, retention: defaultHistoryDays, days: map[string][]VBucksMission{}}
+
+// loadHistory reads the stored history from path, keeping retention days
+func loadHistory(path string, retention int) (*historyStore, error) {
+	store := &historyStore{path: path, retention: retention, days: map[string][]VBucksMission{}}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return store, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, &store.days); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	// Days recorded before areas were normalized may spell them differently
+	for _, missions := range store.days {
+		for i := range missions {
+			missions[i].Area = normalizeArea(missions[i].Area)
+		}
+	}
+
+	return store, nil
+}
+
+// historyRetention reads HISTORY_DAYS, falling back to defaultHistoryDays
+func historyRetention() (int, error) {
+	v := os.Getenv("HISTORY_DAYS")
+	if v == "" {
+		return defaultHistoryDays, nil
+	}
+
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("invalid HISTORY_DAYS %q: must be a positive number of days", v)
+	}
+	return days, nil
+}
+
+// record stores the missions seen on the day of t and drops days that fell
+// out of the retention window
+func (h *historyStore) record(t time.Time, vbucksMissions []VBucksMission) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.days[t.UTC().Format(dateLayout)] = vbucksMissions
+
+	oldest := h.oldestDate(t)
+	for date := range h.days {
+		if date < oldest {
+			delete(h.days, date)
+		}
+	}
+
+	data, err := json.Marshal(h.days)
+	if err != nil {
+		log.Printf("Error encoding history: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(h.path, data, 0644); err != nil {
+		log.Printf("Error writing history file: %v", err)
+	}
+}
+
+// missionsOn returns the missions recorded for a date, if any
+func (h *historyStore) missionsOn(date string) ([]VBucksMission, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	missions, ok := h.days[date]
+	return missions, ok
+}
+
+// dates returns the recorded dates, oldest first
+func (h *historyStore) dates() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dates := make([]string, 0, len(h.days))
+	for date := range h.days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// oldestDate is the first date still inside the retention window as of t
+func (h *historyStore) oldestDate(t time.Time) string {
+	return t.UTC().AddDate(0, 0, -(h.retention - 1)).Format(dateLayout)
+}
+
+// historyRecord is one mission in the exported history, tagged with the date
+// it was scraped on
+type historyRecord struct {
+	Date string
+	VBucksMission
+}
+
+// writeJSON streams every recorded mission, oldest first, as a JSON array or
+// as newline-delimited JSON
+// Records are encoded one at a time so large histories aren't built up in memory
+func (h *historyStore) writeJSON(w io.Writer, ndjson bool) error {
+	h.mu.Lock()
+	dates := make([]string, 0, len(h.days))
+	days := make(map[string][]VBucksMission, len(h.days))
+	for date, missions := range h.days {
+		dates = append(dates, date)
+		days[date] = missions
+	}
+	h.mu.Unlock()
+	sort.Strings(dates)
+
+	separator, end := []byte(","), []byte("]\n")
+	if ndjson {
+		separator, end = nil, nil
+	} else if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+	for _, date := range dates {
+		for _, mission := range days[date] {
+			if !first {
+				if _, err := w.Write(separator); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			if err := enc.Encode(historyRecord{Date: date, VBucksMission: mission}); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := w.Write(end)
+	return err
+}
+
+// parseHistoryDate validates a YYYY-MM-DD argument and checks that it falls
+// inside the retention window, before today
+func (h *historyStore) parseHistoryDate(arg string) (string, error) {
+	day, err := time.Parse(dateLayout, arg)
+	if err != nil {
+		return "", fmt.Errorf("%q isn't a date, use YYYY-MM-DD", arg)
+	}
+
+	date := day.Format(dateLayout)
+	now := time.Now().UTC()
+	if date >= now.Format(dateLayout) {
+		return "", fmt.Errorf("%s isn't in the past", date)
+	}
+	if date < h.oldestDate(now) {
+		return "", fmt.Errorf("history only goes back %d days, to %s", h.retention, h.oldestDate(now))
+	}
+
+	return date, nil
+}
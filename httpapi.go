@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// adminToken protects the HTTP endpoints that dump stored data; set with
+// ADMIN_TOKEN, and required whenever the server is reachable publicly
+var adminToken string
+
+// startHTTPServer serves the HTTP API on addr
+func startHTTPServer(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missions", gzipHandler(handleMissions))
+	if !replicaMode {
+		mux.HandleFunc("/history.json", requireAdminToken(gzipHandler(handleHistoryJSON)))
+	}
+
+	log.Printf("HTTP API listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatalf("HTTP server failed: %v", err)
+	}
+}
+
+// loadHTTPConfig returns the HTTP_ADDR to listen on, empty if the HTTP API is
+// disabled
+func loadHTTPConfig() string {
+	return os.Getenv("HTTP_ADDR")
+}
+
+// loadAdminToken reads ADMIN_TOKEN
+func loadAdminToken() {
+	adminToken = os.Getenv("ADMIN_TOKEN")
+}
+
+// requireAdminToken rejects requests without the admin token, passed as a
+// bearer token or a token query parameter; it's a no-op if no token is set
+func requireAdminToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		configMu.RLock()
+		want := adminToken
+		configMu.RUnlock()
+
+		if want != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" {
+				token = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// handleHistoryJSON streams the whole history, one record per mission with its
+// date, as a JSON array or as NDJSON with ?format=ndjson
+func handleHistoryJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ndjson := r.URL.Query().Get("format") == "ndjson" || strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	if err := history.writeJSON(w, ndjson); err != nil {
+		log.Printf("Error streaming history: %v", err)
+	}
+}
+
+// handleMissions serves today's missions as JSON, CSV or a plain-text list,
+// depending on the Accept header
+// Replicas serve what the primary scraped instead of scraping themselves
+func handleMissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	media := negotiateMissionsType(r.Header.Get("Accept"))
+	if media == "" {
+		http.Error(w, "not acceptable: use application/json, text/csv or text/plain", http.StatusNotAcceptable)
+		return
+	}
+
+	var response missionsResponse
+	if replicaMode {
+		var err error
+		response, err = replicaMissions()
+		if err != nil {
+			log.Printf("Error serving replica missions: %v", err)
+			http.Error(w, "missions unavailable", http.StatusServiceUnavailable)
+			return
+		}
+	} else {
+		missions, freshness := getMissions()
+		response = missionsResponse{UpdatedAt: freshness.UpdatedAt, Stale: freshness.Stale, Missions: missions}
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	// Let clients poll cheaply: the data only changes when it's scraped again
+	if notModified(w, r, response.UpdatedAt, missionsETag(response, media)) {
+		return
+	}
+
+	var err error
+	switch media {
+	case mediaCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		err = writeMissionsCSV(w, response.Missions)
+	case mediaPlain:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = io.WriteString(w, stripMarkdownV2(formatMissionList(formatOptions{}, response.Missions))+"\n")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(response)
+	}
+	if err != nil {
+		log.Printf("Error writing missions: %v", err)
+	}
+}
+
+// missionsETag is the entity tag of a /missions representation, taken from
+// the missions' content hash so rescrapes of the same missions keep it
+func missionsETag(response missionsResponse, media string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%t", missionsHash(response.Missions), media, response.Stale)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists the tag, using
+// the weak comparison the header calls for
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag and Last-Modified headers and answers 304 Not
+// Modified if the client's copy is current: per If-None-Match when sent,
+// otherwise per If-Modified-Since
+// Data without a timestamp, such as a fixture, only gets the ETag
+func notModified(w http.ResponseWriter, r *http.Request, updated time.Time, etag string) bool {
+	w.Header().Set("ETag", etag)
+	// HTTP dates have second precision
+	updated = updated.UTC().Truncate(time.Second)
+	if !updated.IsZero() {
+		w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if updated.IsZero() || err != nil || updated.After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
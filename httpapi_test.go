@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getMissionsEndpoint requests /missions with the given headers
+func getMissionsEndpoint(t *testing.T, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/missions", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	handleMissions(rec, req)
+	return rec
+}
+
+func TestMissionsContentNegotiation(t *testing.T) {
+	withFixture(t, testMissions)
+
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{accept: "", status: http.StatusOK, contentType: "application/json"},
+		{accept: "*/*", status: http.StatusOK, contentType: "application/json"},
+		{accept: "application/json", status: http.StatusOK, contentType: "application/json"},
+		{accept: "text/csv", status: http.StatusOK, contentType: "text/csv; charset=utf-8"},
+		{accept: "text/plain", status: http.StatusOK, contentType: "text/plain; charset=utf-8"},
+		{accept: "text/csv;q=0.5, text/plain", status: http.StatusOK, contentType: "text/plain; charset=utf-8"},
+		{accept: "image/png", status: http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		rec := getMissionsEndpoint(t, map[string]string{"Accept": tt.accept})
+		if rec.Code != tt.status {
+			t.Errorf("Accept %q: status %d, want %d", tt.accept, rec.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, got, tt.contentType)
+		}
+	}
+}
+
+func TestMissionsBodies(t *testing.T) {
+	withFixture(t, testMissions)
+
+	var response missionsResponse
+	if err := json.NewDecoder(getMissionsEndpoint(t, nil).Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Missions) != len(testMissions) || response.Missions[0].Area != "Twine Peaks" {
+		t.Errorf("JSON missions = %+v, want %+v", response.Missions, testMissions)
+	}
+
+	rows, err := csv.NewReader(getMissionsEndpoint(t, map[string]string{"Accept": "text/csv"}).Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "Area,PowerLevel,Amount,MissionType,Modifiers" || rows[2][1] != "76-82" {
+		t.Errorf("CSV rows = %q", rows)
+	}
+
+	plain := getMissionsEndpoint(t, map[string]string{"Accept": "text/plain"}).Body.String()
+	if !strings.Contains(plain, "1. PL 140 Ride the Lightning in Twine Peaks - 80 V-Bucks") || strings.Contains(plain, `\`) {
+		t.Errorf("plain text body isn't the unescaped list:\n%s", plain)
+	}
+}
+
+func TestNegotiateMissionsType(t *testing.T) {
+	tests := map[string]string{
+		"":                          mediaJSON,
+		"*/*":                       mediaJSON,
+		"application/*":             mediaJSON,
+		"TEXT/CSV":                  mediaCSV,
+		"text/*":                    mediaPlain,
+		"text/plain, text/csv":      mediaPlain,
+		"text/plain;q=0.2, */*;q=1": mediaJSON,
+		"text/csv;q=0":              "",
+		"image/png, audio/*":        "",
+	}
+	for accept, want := range tests {
+		if got := negotiateMissionsType(accept); got != want {
+			t.Errorf("negotiateMissionsType(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+// checkNotModified runs notModified on a request with the given headers
+func checkNotModified(headers map[string]string, updated time.Time, etag string) (bool, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/missions", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	return notModified(rec, req, updated, etag), rec
+}
+
+func TestNotModifiedIfModifiedSince(t *testing.T) {
+	updated := time.Date(2024, 3, 4, 6, 30, 15, 500, time.UTC)
+	tests := []struct {
+		name  string
+		since string
+		want  bool
+	}{
+		{name: "no header", since: "", want: false},
+		{name: "before the cache", since: updated.Add(-time.Minute).Format(http.TimeFormat), want: false},
+		{name: "at the cache", since: updated.Format(http.TimeFormat), want: true},
+		{name: "after the cache", since: updated.Add(time.Hour).Format(http.TimeFormat), want: true},
+		{name: "unparseable", since: "yesterday", want: false},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.since != "" {
+			headers["If-Modified-Since"] = tt.since
+		}
+		got, rec := checkNotModified(headers, updated, `"tag"`)
+		if got != tt.want {
+			t.Errorf("%s: notModified = %v, want %v", tt.name, got, tt.want)
+		}
+		if got && rec.Code != http.StatusNotModified {
+			t.Errorf("%s: status %d, want 304", tt.name, rec.Code)
+		}
+		if lm := rec.Header().Get("Last-Modified"); lm != "Mon, 04 Mar 2024 06:30:15 GMT" {
+			t.Errorf("%s: Last-Modified = %q", tt.name, lm)
+		}
+	}
+
+	// Without a timestamp there's nothing to compare with
+	if got, rec := checkNotModified(map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)}, time.Time{}, `"tag"`); got || rec.Header().Get("Last-Modified") != "" {
+		t.Errorf("data without a timestamp: notModified = %v, Last-Modified %q", got, rec.Header().Get("Last-Modified"))
+	}
+}
+
+func TestNotModifiedIfNoneMatch(t *testing.T) {
+	updated := time.Date(2024, 3, 4, 6, 30, 0, 0, time.UTC)
+	etag := `"abc123"`
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "matching", headers: map[string]string{"If-None-Match": `"abc123"`}, want: true},
+		{name: "weak match", headers: map[string]string{"If-None-Match": `W/"abc123"`}, want: true},
+		{name: "in a list", headers: map[string]string{"If-None-Match": `"old", "abc123"`}, want: true},
+		{name: "any", headers: map[string]string{"If-None-Match": `*`}, want: true},
+		{name: "not matching", headers: map[string]string{"If-None-Match": `"old"`}, want: false},
+		// If-None-Match takes precedence over If-Modified-Since
+		{name: "not matching but not modified since", headers: map[string]string{"If-None-Match": `"old"`, "If-Modified-Since": updated.Format(http.TimeFormat)}, want: false},
+	}
+	for _, tt := range tests {
+		got, rec := checkNotModified(tt.headers, updated, etag)
+		if got != tt.want {
+			t.Errorf("%s: notModified = %v, want %v", tt.name, got, tt.want)
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("%s: ETag = %q, want %q", tt.name, rec.Header().Get("ETag"), etag)
+		}
+	}
+}
+
+func TestMissionsETag(t *testing.T) {
+	withFixture(t, testMissions)
+
+	first := getMissionsEndpoint(t, nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("/missions sent no ETag")
+	}
+
+	if rec := getMissionsEndpoint(t, map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("matching If-None-Match: status %d with %d bytes, want an empty 304", rec.Code, rec.Body.Len())
+	}
+	if rec := getMissionsEndpoint(t, map[string]string{"If-None-Match": `"stale"`}); rec.Code != http.StatusOK {
+		t.Errorf("non-matching If-None-Match: status %d, want 200", rec.Code)
+	}
+
+	// Each representation has its own tag
+	if csvTag := getMissionsEndpoint(t, map[string]string{"Accept": "text/csv"}).Header().Get("ETag"); csvTag == etag {
+		t.Error("JSON and CSV responses share an ETag")
+	}
+
+	// The tag follows the content, not when it was scraped
+	later := missionsResponse{UpdatedAt: time.Now(), Missions: testMissions}
+	if missionsETag(later, mediaJSON) != missionsETag(missionsResponse{Missions: testMissions}, mediaJSON) {
+		t.Error("the ETag changed with the timestamp alone")
+	}
+	changed := append([]VBucksMission{{Area: "Stonewood", Amount: "20"}}, testMissions...)
+	if missionsETag(missionsResponse{Missions: changed}, mediaJSON) == missionsETag(missionsResponse{Missions: testMissions}, mediaJSON) {
+		t.Error("the ETag didn't change with the missions")
+	}
+}
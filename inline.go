@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// inlineCacheTime is how long, in seconds, Telegram may cache an inline answer
+const inlineCacheTime = 300
+
+// answerInlineQuery replies to an inline query with today's missions as a
+// single article the user can drop into any chat
+// Inline mode has to be enabled for the bot with BotFather (/setinline)
+func answerInlineQuery(bot *tgbotapi.BotAPI, query *tgbotapi.InlineQuery) {
+	missions, freshness := getMissions()
+
+	// Key the result on the day so clients don't reuse yesterday's article
+	id := "vbucks-" + time.Now().UTC().Format("2006-01-02")
+	article := tgbotapi.NewInlineQueryResultArticleMarkdownV2(id,
+		"Today's V-Bucks missions", formatMissionsForChat(ChatPreferences{}, formatOptions{Freshness: freshness}, missions))
+	article.Description = fmt.Sprintf("%d missions, %d V-Bucks total",
+		len(missions), totalVBucks(missions))
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       []interface{}{article},
+		CacheTime:     inlineCacheTime,
+	}
+
+	if _, err := bot.Request(answer); err != nil {
+		log.Printf("Error answering inline query: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// minInterval is the least time between two fetches of the source, zero
+// when it can be fetched on every scrape; loadSources checks that it parses
+func (s Source) minInterval() time.Duration {
+	d, _ := time.ParseDuration(s.MinInterval)
+	return d
+}
+
+// sourcePoll is the last successful fetch of a source
+type sourcePoll struct {
+	at       time.Time
+	missions []VBucksMission
+}
+
+// lastPolls holds every source's last successful fetch, by source name, so
+// sources with a MinInterval can be served from it until they're due again
+var lastPolls = struct {
+	mu     sync.Mutex
+	byName map[string]sourcePoll
+}{byName: map[string]sourcePoll{}}
+
+// recentPoll returns the source's last fetch while it's too recent to fetch
+// the source again; ok is false once the source is due, or once a daily
+// reset has passed since, as the missions it holds are then yesterday's
+func recentPoll(src Source, now time.Time) (poll sourcePoll, ok bool) {
+	interval := src.minInterval()
+	if interval <= 0 {
+		return sourcePoll{}, false
+	}
+
+	lastPolls.mu.Lock()
+	defer lastPolls.mu.Unlock()
+
+	poll, ok = lastPolls.byName[src.Name]
+	if !ok || now.Sub(poll.at) >= interval || !nextReset(poll.at).After(now) {
+		return sourcePoll{}, false
+	}
+	return poll, true
+}
+
+// recordPoll remembers a successful fetch of the source
+func recordPoll(name string, missions []VBucksMission, at time.Time) {
+	lastPolls.mu.Lock()
+	defer lastPolls.mu.Unlock()
+
+	lastPolls.byName[name] = sourcePoll{at: at, missions: missions}
+}
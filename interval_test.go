@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecentPoll(t *testing.T) {
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		minInterval string
+		polledAt    time.Time
+		now         time.Time
+		wantReused  bool
+	}{
+		{name: "within interval", minInterval: "30m", polledAt: day.Add(12 * time.Hour), now: day.Add(12*time.Hour + 10*time.Minute), wantReused: true},
+		{name: "interval elapsed", minInterval: "30m", polledAt: day.Add(12 * time.Hour), now: day.Add(12*time.Hour + 30*time.Minute)},
+		{name: "no interval", minInterval: "", polledAt: day.Add(12 * time.Hour), now: day.Add(12*time.Hour + time.Minute)},
+		// Polled at 00:05, before the 00:10 reset, and asked again after it
+		{name: "crosses the reset", minInterval: "30m", polledAt: day.Add(5 * time.Minute), now: day.Add(12 * time.Minute)},
+		{name: "after the reset", minInterval: "30m", polledAt: day.Add(10 * time.Minute), now: day.Add(20 * time.Minute), wantReused: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := Source{Name: "test", Kind: SourceKindHTML, MinInterval: tt.minInterval}
+			recordPoll(src.Name, []VBucksMission{{Amount: "80", Area: "Twine Peaks"}}, tt.polledAt)
+			t.Cleanup(func() {
+				lastPolls.mu.Lock()
+				delete(lastPolls.byName, src.Name)
+				lastPolls.mu.Unlock()
+			})
+
+			if _, ok := recentPoll(src, tt.now); ok != tt.wantReused {
+				t.Errorf("recentPoll reused = %v, want %v", ok, tt.wantReused)
+			}
+		})
+	}
+}
+
+// withSources swaps the configured sources for the test
+func withSources(t *testing.T, srcs ...Source) {
+	t.Helper()
+	saved := sources
+	sources = srcs
+	t.Cleanup(func() {
+		sources = saved
+		lastPolls.mu.Lock()
+		lastPolls.byName = map[string]sourcePoll{}
+		lastPolls.mu.Unlock()
+	})
+}
+
+func TestFetchMissionsHonorsMinInterval(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		fmt.Fprintf(w, `[{"Area": "Twine Peaks", "PowerLevel": "140", "Amount": "%d", "MissionType": "Ride the Lightning"}]`, 80+n)
+	}))
+	defer srv.Close()
+
+	withSources(t,
+		Source{Name: "slow", Kind: SourceKindJSON, URL: srv.URL + "/slow", MinInterval: "1h"},
+		Source{Name: "fast", Kind: SourceKindJSON, URL: srv.URL + "/fast"},
+	)
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := fetchMissions(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The source with an interval is fetched once, the other on every scrape
+	if got := atomic.LoadInt32(&hits); got != 4 {
+		t.Errorf("sources were fetched %d times over 3 scrapes, want 4", got)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// defaultKeywords are the phrases that trigger the missions reply
+	defaultKeywords = "vbucks?"
+	// defaultKeywordCooldown is the least time between two keyword replies in a chat
+	defaultKeywordCooldown = 10 * time.Minute
+)
+
+var (
+	// keywords are the lowercase phrases that trigger the missions reply in
+	// chats that turned on /keywords
+	keywords = strings.Split(defaultKeywords, ",")
+	// keywordCooldown is the least time between two keyword replies in a chat
+	keywordCooldown = defaultKeywordCooldown
+)
+
+// keywordReplies remembers when each chat last got a keyword reply
+var keywordReplies = struct {
+	mu   sync.Mutex
+	last map[int64]time.Time
+}{last: map[int64]time.Time{}}
+
+// loadKeywords reads KEYWORDS and KEYWORD_COOLDOWN
+func loadKeywords() error {
+	cooldown, err := durationSetting("KEYWORD_COOLDOWN", defaultKeywordCooldown)
+	if err != nil {
+		return err
+	}
+
+	list := os.Getenv("KEYWORDS")
+	if list == "" {
+		list = defaultKeywords
+	}
+
+	var phrases []string
+	for _, phrase := range strings.Split(list, ",") {
+		if phrase = strings.ToLower(strings.TrimSpace(phrase)); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+
+	keywords = phrases
+	keywordCooldown = cooldown
+	return nil
+}
+
+// matchesKeyword reports whether text contains one of the keywords
+func matchesKeyword(text string) bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	text = strings.ToLower(text)
+	for _, phrase := range keywords {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKeyword replies with the missions to a plain message containing a
+// keyword, in chats that turned keywords on and outside the cooldown
+func handleKeyword(t *tenant, msg *tgbotapi.Message) {
+	if !t.prefs.get(msg.Chat.ID).Keywords || !matchesKeyword(msg.Text) {
+		return
+	}
+
+	configMu.RLock()
+	cooldown := keywordCooldown
+	configMu.RUnlock()
+
+	now := time.Now()
+	keywordReplies.mu.Lock()
+	if last, ok := keywordReplies.last[msg.Chat.ID]; ok && now.Sub(last) < cooldown {
+		keywordReplies.mu.Unlock()
+		return
+	}
+	keywordReplies.last[msg.Chat.ID] = now
+	keywordReplies.mu.Unlock()
+
+	showMissions(t, msg.Chat.ID, "")
+}
+
+// setKeywords handles /keywords on|off
+func setKeywords(store *preferenceStore, chatID int64, args string) string {
+	var enabled bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return "Usage: /keywords on or /keywords off"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.Keywords = enabled }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if enabled {
+		configMu.RLock()
+		phrases := strings.Join(keywords, "\", \"")
+		configMu.RUnlock()
+		return "I'll reply with today's missions to messages containing \"" + phrases + "\"."
+	}
+	return "I'll only answer commands."
+}
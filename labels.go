@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// chatLabelStore keeps the operator's friendly names for chat IDs, shown in
+// admin views instead of the bare numbers
+type chatLabelStore struct {
+	mu     sync.Mutex
+	path   string
+	labels map[int64]string
+}
+
+// chatLabels is shared by every bot so a chat keeps its label across them
+var chatLabels = &chatLabelStore{path: labelsFile, labels: map[int64]string{}}
+
+// load reads the stored labels, if any
+func (s *chatLabelStore) load() error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", s.path, err)
+	}
+
+	labels := map[int64]string{}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", s.path, err)
+	}
+
+	s.mu.Lock()
+	s.labels = labels
+	s.mu.Unlock()
+	return nil
+}
+
+// set labels a chat and saves the store; an empty label removes it
+func (s *chatLabelStore) set(chatID int64, label string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if label == "" {
+		delete(s.labels, chatID)
+	} else {
+		s.labels[chatID] = label
+	}
+
+	data, err := json.Marshal(s.labels)
+	if err != nil {
+		return fmt.Errorf("failed to encode chat labels: %v", err)
+	}
+	if err := ioutil.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", s.path, err)
+	}
+	return nil
+}
+
+// name renders a chat for admin views: its label with the ID, or just the ID
+func (s *chatLabelStore) name(chatID int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if label, ok := s.labels[chatID]; ok {
+		return fmt.Sprintf("%s (%d)", label, chatID)
+	}
+	return strconv.FormatInt(chatID, 10)
+}
+
+// setLabel handles /label <chatID> [name]; leaving out the name removes the label
+func setLabel(args string) string {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return "Usage: /label <chat ID> <name>, or /label <chat ID> to remove the label"
+	}
+
+	chatID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Sprintf("%q isn't a chat ID. Usage: /label <chat ID> <name>", fields[0])
+	}
+	label := strings.Join(fields[1:], " ")
+
+	if err := chatLabels.set(chatID, label); err != nil {
+		log.Printf("Error saving chat labels: %v", err)
+		return "Sorry, the label couldn't be saved. Please try again later."
+	}
+
+	if label == "" {
+		return fmt.Sprintf("Removed the label of chat %d.", chatID)
+	}
+	return fmt.Sprintf("Chat %d is now shown as %q.", chatID, label)
+}
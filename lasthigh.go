@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLastHighAmount is the V-Bucks a mission has to beat to count for
+// /lasthigh when no amount is given
+const defaultLastHighAmount = 50
+
+// formatLastHigh handles /lasthigh <area> [V-Bucks]: the most recent day within
+// the retained history the area had a mission worth more than the amount
+func formatLastHigh(h *historyStore, args string, now time.Time) string {
+	fields := strings.Fields(args)
+	amount := defaultLastHighAmount
+	if len(fields) > 1 {
+		if n, err := strconv.Atoi(fields[len(fields)-1]); err == nil && n > 0 {
+			amount = n
+			fields = fields[:len(fields)-1]
+		}
+	}
+	if len(fields) == 0 {
+		return "Usage: /lasthigh <area> [V-Bucks], e.g. /lasthigh twine peaks 80"
+	}
+	area := resolveArea(strings.Join(fields, " "))
+
+	dates := h.dates()
+	oldest := h.oldestDate(now)
+	for i := len(dates) - 1; i >= 0 && dates[i] >= oldest; i-- {
+		missions, _ := h.missionsOn(dates[i])
+
+		var best *VBucksMission
+		for j, mission := range missions {
+			if !matchesArea(mission.Area, area) || missionAmount(mission) <= amount {
+				continue
+			}
+			if best == nil || missionAmount(mission) > missionAmount(*best) {
+				best = &missions[j]
+			}
+		}
+		if best != nil {
+			return fmt.Sprintf("%s last had a mission worth more than %d V-Bucks on %s: %s, PL %s, %d V-Bucks.",
+				best.Area, amount, dates[i], best.MissionType, best.PowerLevel, missionAmount(*best))
+		}
+	}
+
+	return fmt.Sprintf("No %s missions worth more than %d V-Bucks in the last %s.", area, amount, plural(h.retention, "day"))
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,10 +10,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/gocolly/colly/v2"
 	"github.com/joho/godotenv"
 )
 
@@ -21,104 +22,393 @@ type VBucksMission struct {
 	PowerLevel  string
 	Amount      string
 	MissionType string
+
+	// Modifiers are the mission modifiers (storm elements, enemy types, ...)
+	// when the source lists them
+	Modifiers []string `json:",omitempty"`
+
+	// Date is the YYYY-MM-DD day the mission is for, when the source lists
+	// missions ahead of time; empty means today
+	Date string `json:",omitempty"`
+
+	// Suspect is set when the parser couldn't cleanly extract every field
+	Suspect bool
+}
+
+// suspectReason explains which fields of the mission failed to parse cleanly
+// Returns an empty string when the mission looks complete
+func (m VBucksMission) suspectReason() string {
+	var problems []string
+	if _, err := parseAmount(m.Amount); err != nil {
+		problems = append(problems, "amount")
+	}
+	if _, _, ok := powerRange(m.PowerLevel); !ok {
+		problems = append(problems, "power level")
+	}
+	if m.MissionType == "" {
+		problems = append(problems, "mission type")
+	}
+	if m.Area == "" {
+		problems = append(problems, "area")
+	}
+	return strings.Join(problems, ", ")
 }
 
 // CacheData represents the data we'll be caching
 type CacheData struct {
+	// Version is the cache schema version, see cacheVersion
+	Version        int `json:",omitempty"`
 	Timestamp      time.Time
 	VBucksMissions []VBucksMission
+
+	// NextReset is when the source said the missions rotate next; zero when
+	// it didn't say, and the usual 00:10 UTC reset applies
+	NextReset time.Time `json:",omitempty"`
+
+	// Sources holds what each source reported, keyed by source name, before
+	// the missions were merged
+	Sources map[string][]VBucksMission `json:",omitempty"`
+
+	// Upcoming holds the missions the sources listed for later days
+	Upcoming []VBucksMission `json:",omitempty"`
 }
 
 // File paths
 const (
-	cacheFile = "vbucks_cache.json"
-	envFile   = ".env"
+	cacheFile   = "vbucks_cache.json"
+	envFile     = ".env"
+	sourcesFile = "sources.json"
+	prefsFile   = "chat_prefs.json"
+	historyFile = "vbucks_history.json"
+
+	deadLetterFile  = "dead_letters.jsonl"
+	maintenanceFile = "maintenance.json"
+	labelsFile      = "chat_labels.json"
 )
 
+// adminChatID is the chat allowed to run admin commands; 0 disables them
+var adminChatID int64
+
+// cacheCompress gzips the cache file when set via CACHE_COMPRESS=1
+var cacheCompress bool
+
+// isAdmin reports whether the chat is the configured admin chat
+func isAdmin(chatID int64) bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return adminChatID != 0 && chatID == adminChatID
+}
+
 func main() {
-	// Load environment variables from .env file
-	err := loadEnv()
-	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	// Subcommands run once and exit with a code scripts can act on
+	if len(os.Args) > 1 {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
 	}
 
-	// Get bot token from environment
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if token == "" {
-		log.Fatal("TELEGRAM_BOT_TOKEN not set in .env file")
+	if err := loadConfig(); err != nil {
+		log.Fatal(err)
 	}
 
-	// Initialize Telegram bot
-	bot, err := tgbotapi.NewBotAPI(token)
-	if err != nil {
-		log.Fatalf("Failed to create Telegram bot: %v", err)
+	// Replicas only serve the HTTP API from the primary's data
+	loadReplicaConfig()
+	if replicaMode {
+		addr := loadHTTPConfig()
+		if addr == "" {
+			log.Fatal("REPLICA=1 needs HTTP_ADDR to serve the HTTP API")
+		}
+		log.Printf("Replica mode: serving missions from %s", replicaSourceName())
+		startHTTPServer(addr)
+		return
 	}
 
-	log.Printf("Authorized on account %s", bot.Self.UserName)
+	if err := startStatsd(); err != nil {
+		log.Fatal(err)
+	}
+	startCallback()
+	startTTS()
 
-	// Start listening for updates
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := bot.GetUpdatesChan(u)
+	// Apply config changes on SIGHUP without restarting
+	go watchReload()
 
-	// Handle updates in a separate goroutine
-	go func() {
-		for update := range updates {
-			if update.Message == nil {
-				continue
-			}
+	// Scrape in the background so the first /vbucks doesn't have to wait
+	if os.Getenv("WARM_CACHE") == "1" {
+		go warmCache()
+	}
+
+	// Serve the HTTP API, if enabled
+	if addr := loadHTTPConfig(); addr != "" {
+		go startHTTPServer(addr)
+	}
 
-			// Log the chat ID for setup purposes
-			log.Printf("Received message from chat ID: %d", update.Message.Chat.ID)
-
-			// Process commands
-			if update.Message.IsCommand() {
-				switch update.Message.Command() {
-				case "start":
-					// Send welcome message and show missions
-					welcomeMsg := "Welcome to the Fortnite V-Bucks Missions Bot!\n\n" +
-						"This bot will notify you of daily V-Bucks missions in Fortnite Save the World.\n\n" +
-						"Here are today's missions:"
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, welcomeMsg)
-					bot.Send(msg)
-
-					// Send V-Bucks missions
-					missions := getMissions()
-					vbucksMsg := tgbotapi.NewMessage(update.Message.Chat.ID, formatMissionsForTelegram(missions))
-					vbucksMsg.ParseMode = "MarkdownV2"
-					bot.Send(vbucksMsg)
-				case "vbucks":
-					// Get missions and send as a message
-					missions := getMissions()
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, formatMissionsForTelegram(missions))
-					msg.ParseMode = "MarkdownV2"
-					bot.Send(msg)
-				case "help":
-					helpText := "Available commands:\n" +
-						"/vbucks - Show today's V-Bucks missions\n" +
-						"/help - Show this help message"
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, helpText)
-					bot.Send(msg)
-				default:
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Unknown command. Try /help")
-					bot.Send(msg)
-				}
+	// Get the bot tokens from environment; more than one runs a bot per token
+	tokens, err := botTokens()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the Telegram bots in the background so a Telegram outage
+	// doesn't hold up the rest of the process
+	for _, token := range tokens {
+		go func(token string) {
+			t, err := newTenant(token, len(tokens) > 1)
+			if err != nil {
+				log.Fatal(err)
 			}
-		}
-	}()
+			// The first bot is the one that posts to the area channels
+			t.primary = token == tokens[0]
+
+			// Handle updates
+			t.run()
+		}(token)
+	}
 
 	// Keep the program running
 	select {}
 }
 
+// loadConfig loads the .env file and everything configured through it
+func loadConfig() error {
+	// Load environment variables from .env file
+	if err := loadEnv(); err != nil {
+		return fmt.Errorf("error loading .env file: %v", err)
+	}
+
+	// Load everything a SIGHUP reload can change
+	if err := loadSettings(); err != nil {
+		return err
+	}
+
+	// Load the mission history used by the comparison commands
+	retention, err := historyRetention()
+	if err != nil {
+		return err
+	}
+	history, err = loadHistory(historyFile, retention)
+	if err != nil {
+		return fmt.Errorf("error loading history: %v", err)
+	}
+
+	// Resume a maintenance window that was running before a restart
+	if err := maintenance.load(); err != nil {
+		return fmt.Errorf("error loading maintenance window: %v", err)
+	}
+
+	if err := chatLabels.load(); err != nil {
+		return fmt.Errorf("error loading chat labels: %v", err)
+	}
+
+	return nil
+}
+
+// loadSettings applies the settings read from the environment and the
+// config files that can change while the bot runs
+func loadSettings() error {
+	// Compressing the cache is opt-in; reads detect either format
+	cacheCompress = os.Getenv("CACHE_COMPRESS") == "1"
+	if err := loadCacheWriteRetries(); err != nil {
+		return err
+	}
+
+	// The headless browser fallback is heavyweight, so it's opt-in
+	headlessFallback = os.Getenv("HEADLESS_FALLBACK") == "1"
+
+	// Load the custom /start greeting, if any
+	if err := loadWelcome(); err != nil {
+		return fmt.Errorf("error loading welcome message: %v", err)
+	}
+
+	// What commands say when there's no mission data at all
+	loadFallbackMessage()
+
+	// Serve canned missions instead of scraping, if a fixture is configured
+	if err := loadFixture(); err != nil {
+		return fmt.Errorf("error loading fixture: %v", err)
+	}
+	if fixtureMode {
+		log.Printf("Fixture mode: serving %d missions from %s", len(fixtureMissions), os.Getenv("FIXTURE_PATH"))
+	}
+
+	// Decide whether mission type watches survive after firing
+	loadWatchConfig()
+
+	// Load the area shorthand used by the area filter and the parser
+	if err := loadAreaAliases(); err != nil {
+		return err
+	}
+
+	// Load the channels the daily missions are split across by area
+	if err := loadAreaChannels(); err != nil {
+		return err
+	}
+
+	// Load the mission durations used by /time
+	if err := loadMissionTimes(); err != nil {
+		return err
+	}
+
+	// Space out requests so the source sites aren't hammered
+	if err := loadScrapeLimits(); err != nil {
+		return err
+	}
+
+	if err := loadHealthThresholds(); err != nil {
+		return err
+	}
+
+	if err := loadKeywords(); err != nil {
+		return err
+	}
+
+	if err := loadWorthThreshold(); err != nil {
+		return err
+	}
+
+	if err := loadPowerLevelMargin(); err != nil {
+		return err
+	}
+
+	// Load the mission sources, if any are configured
+	loaded, err := loadSources()
+	if err != nil {
+		return fmt.Errorf("error loading sources: %v", err)
+	}
+	sources = loaded
+
+	// Where the last scraped page is kept for offline parser debugging
+	loadRawHTMLPath()
+
+	// Get the optional admin chat ID
+	adminChatID = 0
+	if v := os.Getenv("ADMIN_CHAT_ID"); v != "" {
+		adminChatID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid ADMIN_CHAT_ID %q: %v", v, err)
+		}
+	}
+
+	// The token protecting the HTTP API's data dumps
+	loadAdminToken()
+
+	return nil
+}
+
+// defaultEnv is written to .env when there is none; it lists every setting
+const defaultEnv = `# Telegram Bot Configuration
+TELEGRAM_BOT_TOKEN=your_bot_token_here
+
+# Comma-separated tokens to run several bots sharing the same missions (optional)
+# When set, TELEGRAM_BOT_TOKEN is ignored
+TELEGRAM_BOT_TOKENS=
+
+# Chat ID allowed to use admin commands such as /suspect (optional; /whoami shows a chat's ID)
+ADMIN_CHAT_ID=
+
+# Set to 1 to gzip the cache file
+CACHE_COMPRESS=0
+
+# Times a failed cache write is retried, CACHE_WRITE_DELAY apart, before the
+# cache is kept in memory until a write succeeds
+CACHE_WRITE_RETRIES=2
+CACHE_WRITE_DELAY=500ms
+
+# Set to 1 to send subscribers a summary of the days they missed while the bot was down
+CATCH_UP=0
+
+# Set to 1 to scrape in the background at startup so the first request is instant
+WARM_CACHE=0
+
+# Custom /start greeting, or a file containing it (optional)
+# Set WELCOME_MARKDOWN=1 to send it as MarkdownV2
+WELCOME_MESSAGE=
+WELCOME_FILE=
+WELCOME_MARKDOWN=0
+
+# Reply used when scraping fails and there's no cache yet, e.g. on a first
+# run during a source outage (optional)
+FALLBACK_MESSAGE=
+
+# JSON file of missions to serve instead of scraping, for demos and testing (optional)
+FIXTURE_PATH=
+
+# Days of mission history to keep for /compareday
+HISTORY_DAYS=30
+
+# Extra area shorthand, e.g. tp=Twine Peaks,cv=Canny Valley (optional)
+AREA_ALIASES=
+
+# Channels the first bot posts each day's missions to, split by area, e.g.
+# Stonewood=-100123,Twine Peaks=-100456; * catches the unmapped areas (optional)
+AREA_CHANNELS=
+
+# Minutes each mission type takes for /time, e.g. ride the lightning=12,deliver the bomb=25 (optional)
+MISSION_TIMES=
+
+# Set to 1 to keep /watchtype watches after they fire
+WATCH_KEEP=0
+
+# Page scraped when there's no sources.json, e.g. a staging mirror or an archived snapshot
+SCRAPE_URL=https://freethevbucks.com/timed-missions/
+
+# Least time between requests to a site, plus up to SCRAPE_JITTER of random extra
+SCRAPE_DELAY=2s
+SCRAPE_JITTER=1s
+
+# Sources are scraped concurrently; one taking longer than SOURCE_TIMEOUT is skipped
+SOURCE_TIMEOUT=90s
+
+# Comma-separated phrases the bot answers in chats that turned on /keywords,
+# at most once per KEYWORD_COOLDOWN per chat
+KEYWORDS=vbucks?
+KEYWORD_COOLDOWN=10m
+
+# V-Bucks total /worth calls worth playing for chats with no average or bar of their own
+WORTH_THRESHOLD=100
+
+# How many power levels above a player's /mypl level /forme still lists missions
+PL_MARGIN=5
+
+# /health turns red once served data is older than HEALTH_MAX_STALE or
+# HEALTH_MAX_FAILURES scrapes in a row failed
+HEALTH_MAX_STALE=12h
+HEALTH_MAX_FAILURES=3
+
+# Set to 1 to render the source in headless Chrome when it serves an anti-bot challenge
+HEADLESS_FALLBACK=0
+
+# Where the last scraped page is saved for "parse-saved-html"; empty disables it
+RAW_HTML_PATH=last_scrape.html
+
+# Set REPLICA=1 to only serve the HTTP API from a primary instance's data, without
+# the bot or the scraper. Missions come from REPLICA_URL (the primary's /missions)
+# or, when it's empty, from the cache file shared with the primary
+REPLICA=0
+REPLICA_URL=
+
+# StatsD agent to push scrape and message metrics to over UDP, e.g. localhost:8125 (optional)
+STATSD_ADDR=
+
+# URL to POST the outcome of every scrape to as JSON (optional)
+# With CALLBACK_SECRET set, requests carry an X-Signature-256 HMAC of the body
+CALLBACK_URL=
+CALLBACK_SECRET=
+
+# Text-to-speech backend /speak POSTs plain text to, answering with OGG/Opus
+# audio; TTS_TOKEN is sent as a bearer token. Without it /speak replies in text
+TTS_URL=
+TTS_TOKEN=
+
+# Address for the HTTP API, e.g. :8080 (optional)
+# ADMIN_TOKEN protects endpoints such as /history.json; set it if the server is public
+HTTP_ADDR=
+ADMIN_TOKEN=
+`
+
 // loadEnv loads environment variables from .env file
 func loadEnv() error {
 	// Check if .env file exists
 	if _, err := os.Stat(envFile); os.IsNotExist(err) {
 		// Create a default .env file
-		defaultEnv := `# Telegram Bot Configuration
-TELEGRAM_BOT_TOKEN=your_bot_token_here
-`
 		if err := ioutil.WriteFile(envFile, []byte(defaultEnv), 0644); err != nil {
 			return fmt.Errorf("failed to create default .env file: %v", err)
 		}
@@ -129,110 +419,187 @@ TELEGRAM_BOT_TOKEN=your_bot_token_here
 	return godotenv.Load(envFile)
 }
 
+// missionsMu serializes cache reads and scrapes across bots and the notifier
+var missionsMu sync.Mutex
+
+// Freshness tells whether the missions getMissions served are current
+type Freshness struct {
+	// Stale is set when fetching failed and the missions come from an
+	// outdated cache
+	Stale bool
+	// UpdatedAt is when the served missions were fetched
+	UpdatedAt time.Time
+}
+
+// confirmed reports whether the missions come from a scrape that succeeded
+// Scrapes that found no missions container fail instead, so an empty list
+// that is confirmed means the day really has no V-Bucks missions
+func (f Freshness) confirmed() bool {
+	return !f.Stale && !f.UpdatedAt.IsZero()
+}
+
 // getMissions gets missions, using the cache if valid
-func getMissions() []VBucksMission {
-	var vbucksMissions []VBucksMission
+// The Freshness result says whether they had to come from a stale cache
+func getMissions() ([]VBucksMission, Freshness) {
+	missionsMu.Lock()
+	defer missionsMu.Unlock()
+
+	// Fixture mode bypasses the scraper and the cache entirely
+	if fixtureMode {
+		return fixtureMissions, Freshness{}
+	}
 
 	// Try to load from cache first
-	if cachedData, cacheValid := loadFromCache(); cacheValid {
-		vbucksMissions = cachedData.VBucksMissions
-	} else {
-		// If cache is invalid or doesn't exist, fetch new data
-		vbucksMissions = fetchMissions()
-
-		// Save the new data to cache
-		saveToCache(vbucksMissions)
+	cachedData, cacheValid := loadFromCache()
+	if cacheValid {
+		return cachedData.VBucksMissions, Freshness{UpdatedAt: cachedData.Timestamp}
 	}
 
-	return vbucksMissions
-}
+	// Don't scrape during a maintenance window, the cache is all there is
+	if _, paused := maintenance.active(time.Now()); paused {
+		return cachedData.VBucksMissions, Freshness{Stale: true, UpdatedAt: cachedData.Timestamp}
+	}
 
-// fetchMissions scrapes the website for V-Bucks missions
-func fetchMissions() []VBucksMission {
-	// Create a new collector
-	c := colly.NewCollector()
+	// If cache is invalid or doesn't exist, fetch new data
+	vbucksMissions, bySource, err := fetchMissions()
+	if err != nil {
+		// Keep serving whatever we had rather than wiping the cache
+		log.Printf("Error fetching missions, serving the cached ones: %v", err)
+		return cachedData.VBucksMissions, Freshness{Stale: true, UpdatedAt: cachedData.Timestamp}
+	}
 
-	// Create a slice to store V-Bucks missions
-	var vbucksMissions []VBucksMission
+	// Missions listed ahead of time are kept apart until their day
+	vbucksMissions, upcoming := splitUpcoming(vbucksMissions, time.Now())
 
-	// Look for divs containing V-Bucks missions
-	c.OnHTML("div.news-link div.infonotice", func(e *colly.HTMLElement) {
-		// Skip the support-a-creator div
-		if strings.Contains(e.Text, "Use code \"iFeral\"") {
-			return
-		}
+	// Save the new data to cache
+	saveToCache(vbucksMissions, upcoming, bySource)
 
-		text := strings.TrimSpace(e.Text)
+	// Keep today's missions for the history commands
+	history.record(time.Now(), vbucksMissions)
 
-		// Split by "in" to get the area
-		parts := strings.Split(text, " in ")
-		if len(parts) < 2 {
-			return
-		}
+	return vbucksMissions, Freshness{UpdatedAt: time.Now().UTC()}
+}
 
-		area := strings.TrimSpace(parts[1])
-		mainPart := parts[0]
+// refreshMissions scrapes now even when the cache is still valid, returning
+// the missions the cache held before alongside the fresh ones
+func refreshMissions() (previous, current []VBucksMission, err error) {
+	missionsMu.Lock()
+	defer missionsMu.Unlock()
 
-		// Split the main part by spaces
-		fields := strings.Fields(mainPart)
-		if len(fields) < 2 {
-			return
-		}
+	if fixtureMode {
+		return fixtureMissions, fixtureMissions, nil
+	}
+	if _, paused := maintenance.active(time.Now()); paused {
+		return nil, nil, fmt.Errorf("scraping is paused for maintenance")
+	}
 
-		// First field is amount, second is power level, rest is mission type
-		amount := fields[0]
-		powerLevel := fields[1]
-
-		// Check if power level has other text attached
-		powerLevelDigits := ""
-		missionType := ""
-
-		for i, c := range powerLevel {
-			if c >= '0' && c <= '9' {
-				powerLevelDigits += string(c)
-			} else {
-				// Once we hit non-digits, the rest is part of the mission type
-				missionType = powerLevel[i:] + " " + strings.Join(fields[2:], " ")
-				break
-			}
-		}
+	cachedData, _ := loadFromCache()
+	vbucksMissions, bySource, err := fetchMissions()
+	if err != nil {
+		return cachedData.VBucksMissions, nil, err
+	}
 
-		// If we didn't find any non-digits, then the mission type is just the remaining fields
-		if missionType == "" {
-			missionType = strings.Join(fields[2:], " ")
-		}
+	vbucksMissions, upcoming := splitUpcoming(vbucksMissions, time.Now())
+	saveToCache(vbucksMissions, upcoming, bySource)
+	history.record(time.Now(), vbucksMissions)
 
-		mission := VBucksMission{
-			Amount:      amount,
-			PowerLevel:  powerLevelDigits,
-			MissionType: strings.TrimSpace(missionType),
-			Area:        area,
-		}
+	return cachedData.VBucksMissions, vbucksMissions, nil
+}
 
-		vbucksMissions = append(vbucksMissions, mission)
-	})
+// warmCache fills the cache right after startup
+// Requests arriving meanwhile wait for this scrape instead of starting another
+func warmCache() {
+	start := time.Now()
+	missions, freshness := getMissions()
+	if freshness.Stale {
+		log.Printf("Cache warm-up failed after %s, serving the cached missions", time.Since(start).Round(time.Millisecond))
+		return
+	}
+	log.Printf("Cache warm-up done: %d missions in %s", len(missions), time.Since(start).Round(time.Millisecond))
+}
 
-	// Start the scraping process
-	err := c.Visit("https://freethevbucks.com/timed-missions/")
-	if err != nil {
-		log.Fatal(err)
+// formatAge renders a duration the way a person would say it, e.g. "3h 5m"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
 	}
+}
 
-	return vbucksMissions
+// staleWarning is the MarkdownV2 line put before missions served from a stale
+// cache, empty when the missions are current
+func staleWarning(f Freshness) string {
+	if !f.Stale || f.UpdatedAt.IsZero() {
+		return ""
+	}
+	return escapeMarkdown(fmt.Sprintf("⚠️ Data may be outdated (last updated %s ago)", formatAge(time.Since(f.UpdatedAt)))) + "\n\n"
 }
 
-// formatMissionsForTelegram formats the missions as a markdown table for Telegram
+// formatMissionsForTelegram formats today's missions as a markdown table for Telegram
 // Note: We're using MarkdownV2 which requires escaping special characters
 func formatMissionsForTelegram(vbucksMissions []VBucksMission) string {
+	return formatMissionList(formatOptions{}, vbucksMissions)
+}
+
+// formatOptions tweaks how formatMissionList renders a list
+type formatOptions struct {
+	// Day is the YYYY-MM-DD day the missions are from; empty means today
+	Day string
+	// HideTotal leaves out the total line
+	HideTotal bool
+	// Compact renders each mission as just its reward and area
+	Compact bool
+	// Freshness of the missions, used to warn about stale data
+	Freshness Freshness
+}
+
+// dayLabel describes a YYYY-MM-DD day in a sentence; an empty day is today
+func dayLabel(day string) string {
+	if day == "" {
+		return "today"
+	}
+	return "on " + day
+}
+
+// formatMissionList formats the missions as MarkdownV2 according to opts
+func formatMissionList(opts formatOptions, vbucksMissions []VBucksMission) string {
 	var result strings.Builder
+	day := opts.Day
 
 	if len(vbucksMissions) > 0 {
-		result.WriteString("*V\\-Bucks Missions Today*\n\n")
+		if day == "" {
+			result.WriteString("*V\\-Bucks Missions Today*\n\n")
+		} else {
+			result.WriteString(fmt.Sprintf("*V\\-Bucks Missions on %s*\n\n", escapeMarkdown(day)))
+		}
 
 		// Simple list format instead of table (tables are hard to format in Telegram)
 		for i, mission := range vbucksMissions {
-			result.WriteString(fmt.Sprintf("%d\\. PL %s %s in %s \\- *%s V\\-Bucks*\n",
+			// Flag missions the parser wasn't sure about
+			warning := ""
+			if mission.Suspect {
+				warning = "⚠️ "
+			}
+
+			if opts.Compact {
+				result.WriteString(fmt.Sprintf("%d\\. %s*%s V\\-Bucks* — %s\n",
+					i+1,
+					warning,
+					escapeMarkdown(mission.Amount),
+					escapeMarkdown(mission.Area),
+				))
+				continue
+			}
+
+			result.WriteString(fmt.Sprintf("%d\\. %sPL %s %s in %s \\- *%s V\\-Bucks*\n",
 				i+1,
+				warning,
 				escapeMarkdown(mission.PowerLevel),
 				escapeMarkdown(mission.MissionType),
 				escapeMarkdown(mission.Area),
@@ -240,33 +607,258 @@ func formatMissionsForTelegram(vbucksMissions []VBucksMission) string {
 			))
 		}
 
-		// Calculate total
-		total := 0
-		for _, mission := range vbucksMissions {
-			amount, _ := strconv.Atoi(mission.Amount)
-			total += amount
+		if !opts.HideTotal {
+			total, estimated := sumVBucks(vbucksMissions)
+			result.WriteString(fmt.Sprintf("\n*Total: %d V\\-Bucks*%s", total, escapeMarkdown(estimateNote(estimated))))
 		}
-
-		result.WriteString(fmt.Sprintf("\n*Total: %d V\\-Bucks*", total))
+	} else if day == "" && opts.Freshness.unavailable() {
+		result.WriteString(escapeMarkdown(fallbackText()))
+	} else if day == "" && opts.Freshness.confirmed() {
+		result.WriteString("*No V\\-Bucks missions today \\(confirmed\\)*")
 	} else {
-		result.WriteString("*No V\\-Bucks missions found today*")
+		result.WriteString(fmt.Sprintf("*No V\\-Bucks missions found %s*", escapeMarkdown(dayLabel(day))))
 	}
 
 	return result.String()
 }
 
+// amountUnits are the unit suffixes parseAmount drops, in lower case
+var amountUnits = []string{"v-bucks", "vbucks", "v bucks"}
+
+// parseAmount reads a reward written with thousands separators or a unit,
+// such as "1,000", "1.000" or "40 V-Bucks"
+func parseAmount(text string) (int, error) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	for _, unit := range amountUnits {
+		if strings.HasSuffix(s, unit) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit))
+			break
+		}
+	}
+	s = strings.NewReplacer(",", "", ".", "").Replace(s)
+
+	amount, err := strconv.Atoi(s)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("amount %q is not a number", text)
+	}
+	return amount, nil
+}
+
+// amountValue returns the mission's reward as a number
+func (m VBucksMission) amountValue() (int, bool) {
+	amount, err := parseAmount(m.Amount)
+	return amount, err == nil
+}
+
+// estimateAmount reads a reward parseAmount can't, such as "40+" or
+// "up to 80", from its digits
+func estimateAmount(text string) int {
+	digits := strings.Map(func(c rune) rune {
+		if c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, text)
+	amount, _ := strconv.Atoi(digits)
+	return amount
+}
+
+// sumVBucks totals the V-Bucks rewarded by the missions and counts how many
+// amounts had to be estimated because they weren't plain numbers
+func sumVBucks(vbucksMissions []VBucksMission) (total, estimated int) {
+	for _, mission := range vbucksMissions {
+		amount, ok := mission.amountValue()
+		if !ok {
+			amount = estimateAmount(mission.Amount)
+			estimated++
+		}
+		total += amount
+	}
+	return total, estimated
+}
+
+// totalVBucks sums the V-Bucks rewarded by the missions
+func totalVBucks(vbucksMissions []VBucksMission) int {
+	total, _ := sumVBucks(vbucksMissions)
+	return total
+}
+
+// estimateNote is the caveat added to a total including estimated amounts,
+// empty when every amount was read exactly
+func estimateNote(estimated int) string {
+	switch estimated {
+	case 0:
+		return ""
+	case 1:
+		return " (1 value estimated)"
+	default:
+		return fmt.Sprintf(" (%d values estimated)", estimated)
+	}
+}
+
+// formatMissionsSummary is the one-line /vbucksshort reply, counting the
+// missions that pass the chat's area and power level filters
+func formatMissionsSummary(prefs ChatPreferences, vbucksMissions []VBucksMission) string {
+	vbucksMissions = filterForChat(prefs, vbucksMissions)
+
+	if len(vbucksMissions) == 0 {
+		return "No V-Bucks missions today — /vbucks for details."
+	}
+
+	noun := "missions"
+	if len(vbucksMissions) == 1 {
+		noun = "mission"
+	}
+	total, estimated := sumVBucks(vbucksMissions)
+	return fmt.Sprintf("%d %s, %d V-Bucks total%s — /vbucks for details.", len(vbucksMissions), noun, total, estimateNote(estimated))
+}
+
+// formatRepeatNote is the MarkdownV2 note sent instead of the full list on
+// days with the same missions as yesterday
+func formatRepeatNote(vbucksMissions []VBucksMission) string {
+	total, estimated := sumVBucks(vbucksMissions)
+	return escapeMarkdown(fmt.Sprintf("Same missions as yesterday: %d missions, %d V-Bucks%s. /vbucks for the list.",
+		len(vbucksMissions), total, estimateNote(estimated)))
+}
+
+// formatMissionsForChat formats the missions honoring the chat's preferences,
+// warning first when they come from a stale cache
+func formatMissionsForChat(prefs ChatPreferences, opts formatOptions, vbucksMissions []VBucksMission) string {
+	opts.HideTotal = prefs.HideTotal
+	opts.Compact = prefs.Compact
+	return staleWarning(opts.Freshness) + formatFilteredMissions(prefs, opts, vbucksMissions)
+}
+
+// formatFilteredMissions applies the chat's filters before formatting,
+// explaining what's available when the filters leave nothing to show
+func formatFilteredMissions(prefs ChatPreferences, opts formatOptions, vbucksMissions []VBucksMission) string {
+	day := opts.Day
+
+	if prefs.Area != "" {
+		filtered := filterByArea(vbucksMissions, prefs.Area)
+
+		// Tell the user what's available instead of an empty list
+		if len(filtered) == 0 && len(vbucksMissions) > 0 {
+			return fmt.Sprintf("*No V\\-Bucks missions in %s %s*\n\nAreas with missions: %s",
+				escapeMarkdown(prefs.Area),
+				escapeMarkdown(dayLabel(day)),
+				escapeMarkdown(strings.Join(missionAreas(vbucksMissions), ", ")),
+			)
+		}
+
+		vbucksMissions = filtered
+	}
+
+	if prefs.MinPowerLevel > 0 || prefs.MaxPowerLevel > 0 {
+		filtered := filterByPowerLevel(vbucksMissions, prefs.MinPowerLevel, prefs.MaxPowerLevel)
+
+		if len(filtered) == 0 && len(vbucksMissions) > 0 {
+			return fmt.Sprintf("*No V\\-Bucks missions match your power level filter %s*\n\nUse /plfilter off to see every mission",
+				escapeMarkdown(dayLabel(day)))
+		}
+
+		vbucksMissions = filtered
+	}
+
+	return formatMissionList(opts, vbucksMissions)
+}
+
+// formatSuspectMissions lists the missions flagged as low-confidence along with
+// the fields that failed to parse, as plain text
+func formatSuspectMissions(vbucksMissions []VBucksMission) string {
+	var result strings.Builder
+
+	for _, mission := range vbucksMissions {
+		if !mission.Suspect {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("- amount=%q pl=%q type=%q area=%q (check: %s)\n",
+			mission.Amount,
+			mission.PowerLevel,
+			mission.MissionType,
+			mission.Area,
+			mission.suspectReason(),
+		))
+	}
+
+	if result.Len() == 0 {
+		return "All missions parsed cleanly."
+	}
+
+	return "Low-confidence missions:\n" + result.String()
+}
+
 // escapeMarkdown escapes special characters for Telegram's MarkdownV2 format
+// Anything user-supplied must go through it before being embedded in a
+// MarkdownV2 message; the backslash goes first so the escapes added for the
+// other characters aren't escaped again
 func escapeMarkdown(text string) string {
-	specialChars := []string{"_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!"}
+	specialChars := []string{"\\", "_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!"}
 	for _, char := range specialChars {
 		text = strings.ReplaceAll(text, char, "\\"+char)
 	}
 	return text
 }
 
-// loadFromCache tries to load missions from the cache file
+// stripMarkdownV2 turns MarkdownV2 into plain text by dropping formatting
+// markers and unescaping escaped characters
+func stripMarkdownV2(text string) string {
+	var result strings.Builder
+	escaped := false
+
+	for _, c := range text {
+		switch {
+		case escaped:
+			result.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '*' || c == '_' || c == '~' || c == '`' || c == '|':
+			// Formatting marker, drop it
+		default:
+			result.WriteRune(c)
+		}
+	}
+
+	return result.String()
+}
+
+// loadFromCache tries to load missions from the cache file, or from memory
+// while the file can't be written
 // Returns the cached data and a boolean indicating if the cache is valid
 func loadFromCache() (CacheData, bool) {
+	cacheData, ok := memoryCache.get()
+	if !ok {
+		if cacheData, ok = readCacheFile(); !ok {
+			return CacheData{}, false
+		}
+	}
+
+	now := time.Now().UTC()
+	cacheTime := cacheData.Timestamp
+
+	// A reset stated by the source decides validity when there is one
+	if !cacheData.NextReset.IsZero() && cacheData.NextReset.Sub(cacheTime) <= maxPageResetAhead {
+		return cacheData, now.Before(cacheData.NextReset)
+	}
+
+	// Otherwise the cache is valid if it's from today after 00:10 UTC
+	// Determine if we're past 00:10 UTC today
+	todayReset := time.Date(now.Year(), now.Month(), now.Day(), 0, 10, 0, 0, time.UTC)
+
+	// Cache is valid if:
+	// 1. Cache timestamp is after today's reset
+	// 2. Current time is also after today's reset
+	cacheValid := cacheTime.After(todayReset) && now.After(todayReset) &&
+		cacheTime.Year() == now.Year() &&
+		cacheTime.Month() == now.Month() &&
+		cacheTime.Day() == now.Day()
+
+	return cacheData, cacheValid
+}
+
+// readCacheFile reads and decodes the cache file, if there is one
+func readCacheFile() (CacheData, bool) {
 	var cacheData CacheData
 
 	// Check if cache file exists
@@ -281,36 +873,41 @@ func loadFromCache() (CacheData, bool) {
 		return cacheData, false
 	}
 
+	// Decompress the cache if it was written with CACHE_COMPRESS=1
+	if isGzip(data) {
+		data, err = gunzip(data)
+		if err != nil {
+			log.Printf("Error decompressing cache file: %v", err)
+			return cacheData, false
+		}
+	}
+
 	// Parse JSON data
 	if err := json.Unmarshal(data, &cacheData); err != nil {
 		log.Printf("Error parsing cache file: %v", err)
 		return cacheData, false
 	}
 
-	// Check if cache is still valid
-	// Cache is valid if it's from today after 00:10 UTC
-	now := time.Now().UTC()
-	cacheTime := cacheData.Timestamp
-
-	// Determine if we're past 00:10 UTC today
-	todayReset := time.Date(now.Year(), now.Month(), now.Day(), 0, 10, 0, 0, time.UTC)
-
-	// Cache is valid if:
-	// 1. Cache timestamp is after today's reset
-	// 2. Current time is also after today's reset
-	cacheValid := cacheTime.After(todayReset) && now.After(todayReset) &&
-		cacheTime.Year() == now.Year() &&
-		cacheTime.Month() == now.Month() &&
-		cacheTime.Day() == now.Day()
+	// Upgrade caches written by older versions
+	if err := migrateCache(&cacheData); err != nil {
+		log.Printf("Error migrating cache file: %v", err)
+		return CacheData{}, false
+	}
 
-	return cacheData, cacheValid
+	return cacheData, true
 }
 
 // saveToCache saves the missions data to the cache file
-func saveToCache(missions []VBucksMission) {
+func saveToCache(missions, upcoming []VBucksMission, bySource map[string][]VBucksMission) {
 	cacheData := CacheData{
+		Version:        cacheVersion,
 		Timestamp:      time.Now().UTC(),
 		VBucksMissions: missions,
+		Sources:        bySource,
+		Upcoming:       upcoming,
+	}
+	if reset, ok := fetchStatus.NextReset(cacheData.Timestamp); ok {
+		cacheData.NextReset = reset
 	}
 
 	// Convert to JSON
@@ -320,8 +917,42 @@ func saveToCache(missions []VBucksMission) {
 		return
 	}
 
-	// Write to file
-	if err := ioutil.WriteFile(cacheFile, data, 0644); err != nil {
-		log.Printf("Error writing cache file: %v", err)
+	if cacheCompress {
+		data, err = gzipBytes(data)
+		if err != nil {
+			log.Printf("Error compressing cache data: %v", err)
+			return
+		}
+	}
+
+	// Write to file, keeping the data in memory if that keeps failing
+	writeCache(cacheData, data)
+}
+
+// isGzip reports whether data starts with the gzip magic bytes
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzip decompresses gzip data
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
 	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
 }
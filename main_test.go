@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "50", want: 50},
+		{in: "1,000", want: 1000},
+		{in: "1.000", want: 1000},
+		{in: "80 V-Bucks", want: 80},
+		{in: "80vbucks", want: 80},
+		{in: " 40 v-bucks ", want: 40},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "V-Bucks", wantErr: true},
+		{in: "-50", wantErr: true},
+		{in: "-1,000 V-Bucks", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAmount(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseAmount(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSumVBucksCountsEstimates(t *testing.T) {
+	tests := []struct {
+		name          string
+		amounts       []string
+		total, counts int
+		note          string
+	}{
+		{name: "plain", amounts: []string{"80", "50", "1,000"}, total: 1130, counts: 0, note: ""},
+		{name: "one estimated", amounts: []string{"80", "40+"}, total: 120, counts: 1, note: " (1 value estimated)"},
+		{name: "two estimated", amounts: []string{"about 40", "up to 80", "50"}, total: 170, counts: 2, note: " (2 values estimated)"},
+	}
+	for _, tt := range tests {
+		var missions []VBucksMission
+		for _, amount := range tt.amounts {
+			missions = append(missions, VBucksMission{Amount: amount, Area: "Twine Peaks"})
+		}
+		total, estimated := sumVBucks(missions)
+		if total != tt.total || estimated != tt.counts {
+			t.Errorf("%s: sumVBucks = %d, %d, want %d, %d", tt.name, total, estimated, tt.total, tt.counts)
+		}
+		if note := estimateNote(estimated); note != tt.note {
+			t.Errorf("%s: estimateNote = %q, want %q", tt.name, note, tt.note)
+		}
+	}
+}
+
+func TestTotalLineCaveat(t *testing.T) {
+	exact := formatMissionList(formatOptions{}, []VBucksMission{{Amount: "80", Area: "Twine Peaks"}})
+	if strings.Contains(exact, "estimated") {
+		t.Errorf("total line for exact amounts has a caveat:\n%s", exact)
+	}
+
+	estimated := formatMissionList(formatOptions{}, []VBucksMission{{Amount: "80", Area: "Twine Peaks"}, {Amount: "40+", Area: "Canny Valley"}})
+	if !strings.Contains(estimated, `*Total: 120 V\-Bucks* \(1 value estimated\)`) {
+		t.Errorf("total line for an estimated amount lacks the caveat:\n%s", estimated)
+	}
+}
+
+// markdownSpecials are the characters MarkdownV2 needs escaped outside entities
+const markdownSpecials = "\\_*[]()~`>#+-=|{}.!"
+
+// unescapedSpecial returns the first MarkdownV2 special character in text not
+// preceded by a backslash, or -1 if every one is escaped
+func unescapedSpecial(text string) int {
+	escaped := false
+	for i, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case strings.ContainsRune(markdownSpecials, c):
+			return i
+		}
+	}
+	return -1
+}
+
+// adversarialInputs are strings full of MarkdownV2 markup
+var adversarialInputs = []string{
+	`*bold* _italic_ __underline__ ~strike~ ||spoiler||`,
+	"`code` ```block```",
+	`[link](https://example.com) ![img](x)`,
+	`\*already escaped\* trailing \`,
+	`> quote # heading + - = | { } . !`,
+	`Twine_Peaks*(PL 140)`,
+}
+
+func TestEscapeMarkdownAdversarial(t *testing.T) {
+	for _, in := range adversarialInputs {
+		out := escapeMarkdown(in)
+		if i := unescapedSpecial(out); i >= 0 {
+			t.Errorf("escapeMarkdown(%q) = %q leaves %q unescaped at %d", in, out, out[i], i)
+		}
+		if back := stripMarkdownV2(out); back != in {
+			t.Errorf("stripMarkdownV2(escapeMarkdown(%q)) = %q, want the input back", in, back)
+		}
+	}
+}
+
+func TestMissionListEscapesScrapedText(t *testing.T) {
+	for _, in := range adversarialInputs {
+		missions := []VBucksMission{{Amount: "80", PowerLevel: in, MissionType: in, Area: in}}
+		text := formatMissionList(formatOptions{}, missions)
+
+		// Drop the bot's own bold markers around the title and reward
+		text = strings.NewReplacer("*V\\-Bucks Missions Today*", "", "*80 V\\-Bucks*", "", "*Total: 80 V\\-Bucks*", "").Replace(text)
+		if i := unescapedSpecial(text); i >= 0 {
+			t.Errorf("mission list for %q leaves %q unescaped:\n%s", in, text[i], text)
+		}
+	}
+}
+
+func TestFormatMissionListNoMissions(t *testing.T) {
+	updated := time.Date(2024, 3, 6, 0, 15, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts formatOptions
+		want string
+	}{
+		{name: "confirmed", opts: formatOptions{Freshness: Freshness{UpdatedAt: updated}}, want: "*No V\\-Bucks missions today \\(confirmed\\)*"},
+		{name: "stale cache", opts: formatOptions{Freshness: Freshness{Stale: true, UpdatedAt: updated}}, want: "*No V\\-Bucks missions found today*"},
+		{name: "never fetched", opts: formatOptions{}, want: "*No V\\-Bucks missions found today*"},
+		{name: "past day", opts: formatOptions{Day: "2024-03-01", Freshness: Freshness{UpdatedAt: updated}}, want: "*No V\\-Bucks missions found on 2024\\-03\\-01*"},
+	}
+	for _, tt := range tests {
+		if got := formatMissionList(tt.opts, nil); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s: formatMissionList =\n%s\nwant it to start with\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMissionListDetail(t *testing.T) {
+	missions := []VBucksMission{
+		{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"},
+		{Amount: "50", PowerLevel: "76-82", MissionType: "Fight the Storm", Area: "Canny Valley", Suspect: true},
+	}
+	verbose := "*V\\-Bucks Missions Today*\n\n" +
+		"1\\. PL 140 Ride the Lightning in Twine Peaks \\- *80 V\\-Bucks*\n" +
+		"2\\. ⚠️ PL 76\\-82 Fight the Storm in Canny Valley \\- *50 V\\-Bucks*\n" +
+		"\n*Total: 130 V\\-Bucks*"
+	compact := "*V\\-Bucks Missions Today*\n\n" +
+		"1\\. *80 V\\-Bucks* — Twine Peaks\n" +
+		"2\\. ⚠️ *50 V\\-Bucks* — Canny Valley\n" +
+		"\n*Total: 130 V\\-Bucks*"
+
+	if got := formatMissionList(formatOptions{}, missions); got != verbose {
+		t.Errorf("verbose =\n%s\nwant\n%s", got, verbose)
+	}
+	if got := formatMissionList(formatOptions{Compact: true}, missions); got != compact {
+		t.Errorf("compact =\n%s\nwant\n%s", got, compact)
+	}
+}
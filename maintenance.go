@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maintenanceWindow pauses scraping until a point in time, persisted so a
+// restart during planned source downtime doesn't resume scraping early
+type maintenanceWindow struct {
+	mu    sync.Mutex
+	path  string
+	until time.Time
+}
+
+// maintenance is shared by every bot since they all scrape the same sources
+var maintenance = &maintenanceWindow{path: maintenanceFile}
+
+// maintenanceState is how the window is stored on disk
+type maintenanceState struct {
+	Until time.Time
+}
+
+// load reads the stored window, if any
+func (w *maintenanceWindow) load() error {
+	if _, err := os.Stat(w.path); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", w.path, err)
+	}
+
+	var state maintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", w.path, err)
+	}
+
+	w.mu.Lock()
+	w.until = state.Until
+	w.mu.Unlock()
+	return nil
+}
+
+// set pauses scraping until the given time; a zero time resumes it
+func (w *maintenanceWindow) set(until time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.until = until
+	data, err := json.Marshal(maintenanceState{Until: until})
+	if err != nil {
+		return fmt.Errorf("failed to encode maintenance window: %v", err)
+	}
+	if err := ioutil.WriteFile(w.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", w.path, err)
+	}
+	return nil
+}
+
+// active returns when the current window ends, if scraping is paused
+// The window ends on its own once the time passes
+func (w *maintenanceWindow) active(now time.Time) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.until.IsZero() || !now.Before(w.until) {
+		return time.Time{}, false
+	}
+	return w.until, true
+}
+
+// status is the line /stats shows while scraping is paused
+func (w *maintenanceWindow) status() string {
+	until, ok := w.active(time.Now())
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("Maintenance: scraping paused until %s (%s left), serving the cache\n\n",
+		until.UTC().Format(time.RFC3339), formatAge(time.Until(until)))
+}
+
+// setMaintenance handles /maintenance <duration>|off
+func setMaintenance(args string) string {
+	args = strings.TrimSpace(args)
+
+	switch strings.ToLower(args) {
+	case "":
+		if until, ok := maintenance.active(time.Now()); ok {
+			return fmt.Sprintf("Scraping is paused until %s. Use /maintenance off to resume now.", until.UTC().Format(time.RFC3339))
+		}
+		return "Scraping isn't paused. Use /maintenance <duration>, e.g. /maintenance 2h"
+	case "off":
+		if err := maintenance.set(time.Time{}); err != nil {
+			log.Printf("Error ending maintenance: %v", err)
+			return "Couldn't save the maintenance window, please try again."
+		}
+		return "Maintenance over, scraping resumed."
+	}
+
+	d, err := time.ParseDuration(args)
+	if err != nil || d <= 0 {
+		return fmt.Sprintf("%q isn't a duration, use something like 90m or 2h", args)
+	}
+
+	until := time.Now().Add(d).UTC()
+	if err := maintenance.set(until); err != nil {
+		log.Printf("Error starting maintenance: %v", err)
+		return "Couldn't save the maintenance window, please try again."
+	}
+	return fmt.Sprintf("Scraping paused until %s. Missions are served from the cache meanwhile.", until.Format(time.RFC3339))
+}
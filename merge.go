@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+// slot identifies where and when a mission is, ignoring its reward, so two
+// sources reporting the same mission with different amounts can be told apart
+func (m VBucksMission) slot() string {
+	return strings.Join([]string{m.Area, m.MissionType, m.PowerLevel, m.Date}, "|")
+}
+
+// mergeSources combines what each source reported into one list
+// When several sources report the same slot, the entries of the source with
+// the highest Priority are kept, earlier sources winning ties, and any
+// disagreement on the amount is logged
+func mergeSources(srcs []Source, bySource map[string][]VBucksMission) []VBucksMission {
+	ordered := append([]Source(nil), srcs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	// owner is the source whose entries are kept for each slot
+	owner := map[string]string{}
+	amounts := map[string]string{}
+	var merged []VBucksMission
+	for _, src := range ordered {
+		for _, mission := range bySource[src.Name] {
+			slot := mission.slot()
+			if winner, ok := owner[slot]; ok && winner != src.Name {
+				if amounts[slot] != mission.Amount {
+					log.Printf("Sources disagree on %s %s in %s: keeping %s from %s over %s from %s",
+						mission.MissionType, mission.PowerLevel, mission.Area,
+						amounts[slot], winner, mission.Amount, src.Name)
+				}
+				continue
+			}
+			owner[slot] = src.Name
+			amounts[slot] = mission.Amount
+			merged = append(merged, mission)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeSourcesConflicts(t *testing.T) {
+	twine := func(amount string) VBucksMission {
+		return VBucksMission{Area: "Twine Peaks", PowerLevel: "140", MissionType: "Ride the Lightning", Amount: amount}
+	}
+	canny := VBucksMission{Area: "Canny Valley", PowerLevel: "76-82", MissionType: "Fight the Storm", Amount: "50"}
+	bySource := map[string][]VBucksMission{
+		"site":   {twine("80")},
+		"mirror": {twine("75"), canny},
+	}
+
+	tests := []struct {
+		name string
+		srcs []Source
+		want []VBucksMission
+	}{
+		{
+			name: "higher priority wins",
+			srcs: []Source{{Name: "site", Priority: 1}, {Name: "mirror", Priority: 5}},
+			want: []VBucksMission{twine("75"), canny},
+		},
+		{
+			name: "higher priority wins when listed first",
+			srcs: []Source{{Name: "site", Priority: 5}, {Name: "mirror", Priority: 1}},
+			want: []VBucksMission{twine("80"), canny},
+		},
+		{
+			name: "ties go to the source listed first",
+			srcs: []Source{{Name: "mirror"}, {Name: "site"}},
+			want: []VBucksMission{twine("75"), canny},
+		},
+	}
+	for _, tt := range tests {
+		if got := mergeSources(tt.srcs, bySource); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: mergeSources = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeSourcesKeepsDuplicatesWithinASource(t *testing.T) {
+	// Two identical slots from one source are two missions, not a conflict
+	mission := VBucksMission{Area: "Plankerton", PowerLevel: "40", MissionType: "Retrieve the Data", Amount: "50"}
+	got := mergeSources([]Source{{Name: "site"}}, map[string][]VBucksMission{"site": {mission, mission}})
+	if len(got) != 2 {
+		t.Errorf("mergeSources kept %d missions, want 2", len(got))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// cacheVersion is the schema version of the cache file
+//
+//	0: written before versioning; power level ranges like "76-82" were split
+//	   by the parser into PowerLevel "76" and a MissionType starting with "-82"
+//	1: PowerLevel holds the whole range, normalized to "76-82" or "76"
+const cacheVersion = 1
+
+// legacyRangeTail matches the end of a power level range the old parser left
+// at the start of the mission type
+var legacyRangeTail = regexp.MustCompile(`^-(\d+)\s*(.*)$`)
+
+// migrateCache upgrades cache data written by older versions in place
+// Returns an error for caches written by a newer version than this one
+func migrateCache(data *CacheData) error {
+	if data.Version > cacheVersion {
+		return fmt.Errorf("cache version %d is newer than supported version %d", data.Version, cacheVersion)
+	}
+
+	if data.Version < 1 {
+		migratePowerLevels(data.VBucksMissions)
+		for _, missions := range data.Sources {
+			migratePowerLevels(missions)
+		}
+	}
+
+	data.Version = cacheVersion
+	return nil
+}
+
+// migratePowerLevels rejoins power level ranges the old parser split and
+// normalizes every power level to the "min-max" form
+func migratePowerLevels(vbucksMissions []VBucksMission) {
+	for i := range vbucksMissions {
+		m := &vbucksMissions[i]
+
+		if match := legacyRangeTail.FindStringSubmatch(m.MissionType); match != nil {
+			if _, _, ok := powerRange(m.PowerLevel + "-" + match[1]); ok {
+				m.PowerLevel += "-" + match[1]
+				m.MissionType = match[2]
+			}
+		}
+
+		if min, max, ok := powerRange(m.PowerLevel); ok {
+			if min == max {
+				m.PowerLevel = fmt.Sprint(min)
+			} else {
+				m.PowerLevel = fmt.Sprintf("%d-%d", min, max)
+			}
+		}
+
+		m.Suspect = m.suspectReason() != ""
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestReadCacheFileMigratesLegacyRanges(t *testing.T) {
+	legacy, err := ioutil.ReadFile("testdata/legacy_cache.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inTempDir(t)
+	if err := ioutil.WriteFile(cacheFile, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, ok := readCacheFile()
+	if !ok {
+		t.Fatal("legacy cache couldn't be read")
+	}
+	if cached.Version != cacheVersion {
+		t.Errorf("Version = %d, want %d", cached.Version, cacheVersion)
+	}
+
+	want := []struct{ powerLevel, missionType string }{
+		{"76-82", "Fight the Storm"},
+		{"140", "Ride the Lightning"},
+		{"46-58", "Retrieve the Data"},
+	}
+	for i, w := range want {
+		m := cached.VBucksMissions[i]
+		if m.PowerLevel != w.powerLevel || m.MissionType != w.missionType || m.Suspect {
+			t.Errorf("mission %d = %q %q suspect %v, want %q %q", i+1, m.PowerLevel, m.MissionType, m.Suspect, w.powerLevel, w.missionType)
+		}
+	}
+	if m := cached.Sources["freethevbucks"][0]; m.PowerLevel != "76-82" || m.MissionType != "Fight the Storm" {
+		t.Errorf("per-source mission = %q %q, want migrated too", m.PowerLevel, m.MissionType)
+	}
+}
+
+func TestMigrateCacheRejectsNewerVersion(t *testing.T) {
+	data := CacheData{Version: cacheVersion + 1}
+	if err := migrateCache(&data); err == nil {
+		t.Error("migrateCache accepted a cache from a newer version")
+	}
+}
+
+func TestMigrateCacheLeavesCurrentVersion(t *testing.T) {
+	// A current cache's mission type starting with a dash isn't a split range
+	data := CacheData{Version: cacheVersion, VBucksMissions: []VBucksMission{{PowerLevel: "76", MissionType: "-82 test"}}}
+	if err := migrateCache(&data); err != nil {
+		t.Fatal(err)
+	}
+	if m := data.VBucksMissions[0]; m.PowerLevel != "76" || m.MissionType != "-82 test" {
+		t.Errorf("current cache was migrated: %q %q", m.PowerLevel, m.MissionType)
+	}
+}
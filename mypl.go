@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultPowerLevelMargin is how far above the player's power level /forme
+	// still lists missions
+	defaultPowerLevelMargin = 5
+	// maxPowerLevel is the highest power level /mypl accepts
+	maxPowerLevel = 160
+)
+
+// powerLevelMargin is set with PL_MARGIN
+var powerLevelMargin = defaultPowerLevelMargin
+
+// loadPowerLevelMargin reads PL_MARGIN
+func loadPowerLevelMargin() error {
+	v := os.Getenv("PL_MARGIN")
+	if v == "" {
+		powerLevelMargin = defaultPowerLevelMargin
+		return nil
+	}
+
+	margin, err := strconv.Atoi(v)
+	if err != nil || margin < 0 {
+		return fmt.Errorf("invalid PL_MARGIN %q: must be zero or more power levels", v)
+	}
+	powerLevelMargin = margin
+	return nil
+}
+
+// setMyPowerLevel handles /mypl [n]: with no argument it shows the stored level
+func setMyPowerLevel(store *preferenceStore, chatID int64, args string) string {
+	arg := strings.TrimSpace(args)
+	if arg == "" {
+		if pl := store.get(chatID).MyPowerLevel; pl > 0 {
+			return fmt.Sprintf("Your power level is %d. /forme lists the missions you can do.", pl)
+		}
+		return "Usage: /mypl <power level>, e.g. /mypl 76"
+	}
+
+	pl, err := strconv.Atoi(arg)
+	if err != nil || pl < 1 || pl > maxPowerLevel {
+		return fmt.Sprintf("Usage: /mypl <power level>, a number from 1 to %d", maxPowerLevel)
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.MyPowerLevel = pl }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+	return fmt.Sprintf("Power level set to %d. /forme lists the missions you can do.", pl)
+}
+
+// showMissionsForMe handles /forme: today's missions whose power level range
+// starts at or below the chat's power level plus the margin
+func showMissionsForMe(t *tenant, chatID int64) {
+	prefs := t.prefs.get(chatID)
+	if prefs.MyPowerLevel == 0 {
+		reply(t.bot, chatID, "Set your power level first, e.g. /mypl 76")
+		return
+	}
+
+	configMu.RLock()
+	limit := prefs.MyPowerLevel + powerLevelMargin
+	configMu.RUnlock()
+
+	missions, freshness := getMissions()
+	if prefs.Area != "" {
+		missions = filterByArea(missions, prefs.Area)
+	}
+	doable := filterByPowerLevel(missions, 0, limit)
+
+	if len(doable) == 0 {
+		easiest := 0
+		for _, mission := range missions {
+			if min, _, ok := powerRange(mission.PowerLevel); ok && (easiest == 0 || min < easiest) {
+				easiest = min
+			}
+		}
+		if easiest == 0 {
+			reply(t.bot, chatID, "No V-Bucks missions today.")
+			return
+		}
+		reply(t.bot, chatID, fmt.Sprintf("Nothing at or below PL %d today; the easiest V-Bucks mission is PL %d. Keep leveling and check back tomorrow!", limit, easiest))
+		return
+	}
+
+	header := "*" + escapeMarkdown(fmt.Sprintf("Missions up to PL %d (yours is %d)", limit, prefs.MyPowerLevel)) + "*\n\n"
+	replyMarkdown(t.bot, chatID, staleWarning(freshness)+header+formatMissionList(formatOptions{HideTotal: prefs.HideTotal}, doable))
+}
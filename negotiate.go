@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Media types /missions can be served as
+const (
+	mediaJSON  = "application/json"
+	mediaCSV   = "text/csv"
+	mediaPlain = "text/plain"
+)
+
+// negotiateMissionsType picks the media type to serve /missions as from an
+// Accept header, JSON when it's missing or accepts anything
+// Returns "" when none of the offered types is acceptable
+func negotiateMissionsType(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return mediaJSON
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+
+		q := 1.0
+		for _, param := range params[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		var media string
+		switch mediaRange {
+		case mediaJSON, "*/*", "application/*":
+			media = mediaJSON
+		case mediaCSV:
+			media = mediaCSV
+		case mediaPlain, "text/*":
+			media = mediaPlain
+		default:
+			continue
+		}
+
+		// Earlier ranges win ties, as clients list their preference first
+		if q > bestQ {
+			best, bestQ = media, q
+		}
+	}
+	return best
+}
+
+// writeMissionsCSV writes the missions as CSV with a header row; modifiers
+// are joined with semicolons
+func writeMissionsCSV(w io.Writer, missions []VBucksMission) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Area", "PowerLevel", "Amount", "MissionType", "Modifiers"})
+	for _, m := range missions {
+		cw.Write([]string{m.Area, m.PowerLevel, m.Amount, m.MissionType, strings.Join(m.Modifiers, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
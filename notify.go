@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// notifyDelay gives the source site a moment to publish the new rotation
+	notifyDelay = 5 * time.Minute
+	// notifyMaxAttempts is how many times a notification is tried in total
+	notifyMaxAttempts = 4
+)
+
+// notifyRetryDelay is the wait before the first retry; it doubles each time
+var notifyRetryDelay = 30 * time.Second
+
+// pendingSend is a notification that failed and is waiting to be retried
+type pendingSend struct {
+	chatID   int64
+	text     string
+	attempts int
+	err      error
+}
+
+// nextReset returns the first daily reset (00:10 UTC) after now
+func nextReset(now time.Time) time.Time {
+	now = now.UTC()
+	reset := time.Date(now.Year(), now.Month(), now.Day(), 0, 10, 0, 0, time.UTC)
+	if !reset.After(now) {
+		reset = reset.AddDate(0, 0, 1)
+	}
+	return reset
+}
+
+// runNotifier sends the daily missions to every subscriber shortly after each reset
+func runNotifier(t *tenant) {
+	// Make up for pushes missed while the bot was down, if enabled
+	catchUp(t)
+
+	for {
+		next := nextReset(time.Now()).Add(notifyDelay)
+		log.Printf("Next daily notification at %s", next.Format(time.RFC3339))
+		time.Sleep(time.Until(next))
+
+		missions, freshness := getMissions()
+		broadcastMissions(t, missions, freshness)
+		if t.primary {
+			postAreaChannels(t, missions, freshness)
+		}
+		notifyWatchers(t, missions)
+	}
+}
+
+// broadcastResult counts how a fan-out to the subscribers went
+type broadcastResult struct {
+	Subscribers int
+	Delivered   int
+	// Retrying failed transiently and was handed to the retry queue
+	Retrying int
+}
+
+// broadcastMissions sends the missions to all subscribers and hands transient
+// failures to the retry queue so they don't hold up the fan-out
+func broadcastMissions(t *tenant, vbucksMissions []VBucksMission, freshness Freshness) broadcastResult {
+	subscribers := t.prefs.subscribers()
+
+	// Rotations often repeat, some chats only want a note when they do
+	repeat := isRepeatDay(vbucksMissions, time.Now())
+	today := time.Now().UTC().Format(dateLayout)
+
+	delivered := 0
+	var failed []pendingSend
+	for _, chatID := range subscribers {
+		prefs := t.prefs.get(chatID)
+		total := chatTotal(prefs, vbucksMissions)
+		if err := t.prefs.update(chatID, func(p *ChatPreferences) { addToAverage(p, today, total) }); err != nil {
+			log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		}
+
+		// Weekend players skip weekdays, in their own time zone
+		if prefs.WeekendsOnly && !isWeekendFor(prefs, time.Now()) {
+			continue
+		}
+
+		// Chats that asked for new missions only skip the ones they were sent,
+		// out of those their filters would show
+		missions := vbucksMissions
+		if prefs.OnlyNew {
+			missions = unseenMissions(prefs.Seen, filterForChat(prefs, vbucksMissions))
+			if len(missions) == 0 {
+				markNotified(t.prefs, chatID, today)
+				continue
+			}
+		}
+
+		// Digest chats are measured against their average before today counts
+		text, ok := renderNotification(prefs, missions, total, freshness, repeat)
+		if !ok {
+			markNotified(t.prefs, chatID, today)
+			continue
+		}
+
+		if err := sendNotification(t.bot, chatID, text); err != nil {
+			if isBlocked(err) {
+				unsubscribeBlocked(t.prefs, chatID)
+				continue
+			}
+			if !isTransient(err) {
+				// Already recorded as a dead letter, retrying won't help
+				continue
+			}
+			failed = append(failed, pendingSend{chatID: chatID, text: text, attempts: 1, err: err})
+		} else {
+			delivered++
+		}
+
+		// Transient failures count as sent, the retry queue delivers them
+		if prefs.OnlyNew {
+			markSeen(t.prefs, chatID, missions, time.Now())
+		}
+		markNotified(t.prefs, chatID, today)
+	}
+
+	log.Printf("[%s] Missions sent to %d of %d subscribers, %d queued for retry",
+		t.bot.Self.UserName, delivered, len(subscribers), len(failed))
+
+	if len(failed) > 0 {
+		go retryPendingSends(t, failed)
+	}
+
+	return broadcastResult{Subscribers: len(subscribers), Delivered: delivered, Retrying: len(failed)}
+}
+
+// markNotified records the day as handled for the chat, whether it was sent
+// the missions or had nothing due, so a catch-up doesn't report it as missed
+func markNotified(store *preferenceStore, chatID int64, date string) {
+	if err := store.update(chatID, func(p *ChatPreferences) { p.LastNotified = date }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+	}
+}
+
+// retryPendingSends re-attempts failed notifications with exponential backoff
+// and logs the chats that still couldn't be reached
+func retryPendingSends(t *tenant, queue []pendingSend) {
+	delay := notifyRetryDelay
+
+	for len(queue) > 0 && queue[0].attempts < notifyMaxAttempts {
+		time.Sleep(delay)
+		delay *= 2
+
+		var remaining []pendingSend
+		for _, p := range queue {
+			p.attempts++
+			if err := sendNotification(t.bot, p.chatID, p.text); err != nil {
+				if isBlocked(err) {
+					unsubscribeBlocked(t.prefs, p.chatID)
+					continue
+				}
+				if !isTransient(err) {
+					continue
+				}
+				p.err = err
+				remaining = append(remaining, p)
+				continue
+			}
+			log.Printf("Notification to chat %s delivered on attempt %d", chatLabels.name(p.chatID), p.attempts)
+		}
+		queue = remaining
+	}
+
+	if len(queue) == 0 {
+		return
+	}
+
+	var failures []string
+	for _, p := range queue {
+		failures = append(failures, fmt.Sprintf("%s (%v)", chatLabels.name(p.chatID), p.err))
+		recordDeadLetter(p.chatID, p.text, p.err)
+	}
+	log.Printf("Giving up on %d notifications after %d attempts: %s",
+		len(queue), notifyMaxAttempts, strings.Join(failures, "; "))
+}
+
+// sendNotification sends a rendered missions message to a chat
+func sendNotification(bot *tgbotapi.BotAPI, chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "MarkdownV2"
+	return send(bot, msg)
+}
+
+// isBlocked reports whether Telegram refused the send because the bot was
+// blocked or removed from the chat, which retrying can't fix
+func isBlocked(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == http.StatusForbidden
+}
+
+// isNotModified reports whether Telegram refused an edit because the message
+// already has that text and keyboard, which means there's nothing to do
+func isNotModified(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == http.StatusBadRequest &&
+		strings.Contains(tgErr.Message, "message is not modified")
+}
+
+// unsubscribeBlocked drops a chat that no longer accepts messages from the bot
+func unsubscribeBlocked(store *preferenceStore, chatID int64) {
+	log.Printf("Chat %s blocked the bot, unsubscribing it", chatLabels.name(chatID))
+	if err := store.update(chatID, func(p *ChatPreferences) { p.Subscribed = false }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+	}
+}
+
+// setSubscribed handles /subscribe and /unsubscribe and returns the reply text
+func setSubscribed(store *preferenceStore, chatID int64, subscribed bool) string {
+	if err := store.update(chatID, func(p *ChatPreferences) { p.Subscribed = subscribed }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your subscription couldn't be saved. Please try again later."
+	}
+
+	if subscribed {
+		return "Subscribed! You'll get the V-Bucks missions every day after the reset."
+	}
+	return "Unsubscribed. You won't get daily notifications anymore."
+}
+
+// setShortRepeats handles /shortrepeats and returns the reply text
+func setShortRepeats(store *preferenceStore, chatID int64, args string) string {
+	var short bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		short = true
+	case "off":
+		short = false
+	default:
+		return "Usage: /shortrepeats on or /shortrepeats off"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.ShortRepeats = short }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if short {
+		return "On days with the same missions as yesterday you'll get a short note instead of the full list."
+	}
+	return "You'll get the full list every day, even when it repeats."
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcastRetriesTransientFailures(t *testing.T) {
+	inTempDir(t)
+	delay := notifyRetryDelay
+	notifyRetryDelay = 10 * time.Millisecond
+	t.Cleanup(func() { notifyRetryDelay = delay })
+
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	for chatID := int64(1); chatID <= 4; chatID++ {
+		if err := tn.prefs.update(chatID, func(p *ChatPreferences) { p.Subscribed = true }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Chat 2 is down for the first two attempts, chat 3 doesn't exist and
+	// chat 4 blocked the bot
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	fake.setFail(func(req telegramRequest) (int, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := req.Form.Get("chat_id")
+		attempts[chatID]++
+		switch {
+		case chatID == "2" && attempts[chatID] <= 2:
+			return http.StatusBadGateway, "Bad Gateway"
+		case chatID == "3":
+			return http.StatusBadRequest, "Bad Request: chat not found"
+		case chatID == "4":
+			return http.StatusForbidden, "Forbidden: bot was blocked by the user"
+		}
+		return 0, ""
+	})
+
+	got := broadcastMissions(tn, testMissions, Freshness{UpdatedAt: time.Now()})
+	if want := (broadcastResult{Subscribers: 4, Delivered: 1, Retrying: 1}); got != want {
+		t.Errorf("broadcastMissions = %+v, want %+v", got, want)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := attempts["2"]
+		mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("chat 2 was tried %d times, want it delivered on the third attempt", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if tn.prefs.get(4).Subscribed {
+		t.Error("chat 4 blocked the bot but is still subscribed")
+	}
+	mu.Lock()
+	if attempts["3"] != 1 {
+		t.Errorf("chat 3 was tried %d times, a permanent failure shouldn't be retried", attempts["3"])
+	}
+	mu.Unlock()
+	letters, err := recentDeadLetters(deadLettersShown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 || letters[0].ChatID != 3 {
+		t.Errorf("dead letters = %+v, want only chat 3", letters)
+	}
+}
+
+func TestBroadcastOnlyNewHonorsFilters(t *testing.T) {
+	inTempDir(t)
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	for chatID, area := range map[int64]string{1: "Plankerton", 2: "Twine Peaks"} {
+		if err := tn.prefs.update(chatID, func(p *ChatPreferences) {
+			p.Subscribed, p.OnlyNew, p.Area = true, true, area
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broadcastMissions(tn, testMissions, Freshness{UpdatedAt: time.Now()})
+
+	sentTo := map[string]bool{}
+	fake.mu.Lock()
+	for _, req := range fake.requests {
+		sentTo[req.Form.Get("chat_id")] = true
+	}
+	fake.mu.Unlock()
+
+	// Nothing new in Plankerton, so no empty push
+	if sentTo["1"] {
+		t.Error("chat filtered to Plankerton was sent missions from other areas")
+	}
+	if seen := tn.prefs.get(1).Seen; len(seen) != 0 {
+		t.Errorf("chat 1 marked %v as seen without being shown them", seen)
+	}
+
+	if !sentTo["2"] {
+		t.Fatal("chat filtered to Twine Peaks wasn't notified")
+	}
+	seen := tn.prefs.get(2).Seen
+	if _, ok := seen[testMissions[0].key()]; !ok || len(seen) != 1 {
+		t.Errorf("chat 2 seen = %v, want only the Twine Peaks mission", seen)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// Notification modes a chat can pick with /notifymode
+const (
+	notifyFull    = "full"
+	notifySummary = "summary"
+	notifyDigest  = "digest"
+)
+
+// averageWindow is roughly how many days the rolling average total covers
+const averageWindow = 14
+
+// chatTotal is the V-Bucks on offer among the missions the chat's filters keep
+func chatTotal(prefs ChatPreferences, missions []VBucksMission) int {
+	total, _ := sumVBucks(filterForChat(prefs, missions))
+	return total
+}
+
+// addToAverage folds the total of the day of date into the chat's rolling
+// average, once per day so repeated broadcasts don't skew it
+// Until averageWindow days are in it's a plain mean, then older days fade out
+func addToAverage(p *ChatPreferences, date string, total int) {
+	if p.AverageDate == date {
+		return
+	}
+	p.AverageDate = date
+	if p.AverageDays < averageWindow {
+		p.AverageDays++
+	}
+	p.AverageTotal += (float64(total) - p.AverageTotal) / float64(p.AverageDays)
+}
+
+// aboveAverage reports whether total beats the chat's rolling average
+// Chats without an average yet count every day as above it
+func aboveAverage(prefs ChatPreferences, total int) bool {
+	return prefs.AverageDays == 0 || float64(total) > prefs.AverageTotal
+}
+
+// renderNotification builds the daily push for a chat in its notification
+// mode, with total being what the whole day offers the chat; false means the
+// chat gets nothing today
+func renderNotification(prefs ChatPreferences, missions []VBucksMission, total int, freshness Freshness, repeat bool) (string, bool) {
+	if prefs.NotifyMode == notifyDigest && !aboveAverage(prefs, total) {
+		return "", false
+	}
+
+	switch {
+	case repeat && prefs.ShortRepeats:
+		return formatRepeatNote(missions), true
+	case prefs.NotifyMode == notifySummary && freshness.unavailable():
+		return escapeMarkdown(fallbackText()), true
+	case prefs.NotifyMode == notifySummary:
+		return staleWarning(freshness) + escapeMarkdown(formatMissionsSummary(prefs, missions)), true
+	}
+	return formatMissionsForChat(prefs, formatOptions{Freshness: freshness}, missions), true
+}
+
+// setNotifyMode handles /notifymode and returns the reply text
+func setNotifyMode(store *preferenceStore, chatID int64, args string) string {
+	mode := strings.ToLower(strings.TrimSpace(args))
+	switch mode {
+	case notifyFull, notifySummary, notifyDigest:
+	default:
+		return "Usage: /notifymode full, /notifymode summary or /notifymode digest"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.NotifyMode = mode }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	switch mode {
+	case notifySummary:
+		return "Your daily notification will be a one-line summary."
+	case notifyDigest:
+		return "You'll only get the daily notification when there are more V-Bucks than usual."
+	}
+	return "Your daily notification will list every mission."
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderNotificationModes(t *testing.T) {
+	missions := []VBucksMission{
+		{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"},
+		{Amount: "50", PowerLevel: "76-82", MissionType: "Fight the Storm", Area: "Canny Valley"},
+	}
+	fresh := Freshness{UpdatedAt: time.Now()}
+
+	tests := []struct {
+		name     string
+		prefs    ChatPreferences
+		wantSent bool
+		want     string
+	}{
+		{name: "full", prefs: ChatPreferences{}, wantSent: true, want: "*V\\-Bucks Missions Today*"},
+		{name: "full explicitly", prefs: ChatPreferences{NotifyMode: notifyFull}, wantSent: true, want: "Ride the Lightning in Twine Peaks"},
+		{name: "summary", prefs: ChatPreferences{NotifyMode: notifySummary}, wantSent: true, want: "2 missions, 130 V\\-Bucks total"},
+		{name: "digest without average", prefs: ChatPreferences{NotifyMode: notifyDigest}, wantSent: true, want: "*V\\-Bucks Missions Today*"},
+		{name: "digest above average", prefs: ChatPreferences{NotifyMode: notifyDigest, AverageDays: 5, AverageTotal: 100}, wantSent: true, want: "*Total: 130 V\\-Bucks*"},
+		{name: "digest below average", prefs: ChatPreferences{NotifyMode: notifyDigest, AverageDays: 5, AverageTotal: 200}, wantSent: false},
+		{name: "digest at average", prefs: ChatPreferences{NotifyMode: notifyDigest, AverageDays: 5, AverageTotal: 130}, wantSent: false},
+	}
+	for _, tt := range tests {
+		text, sent := renderNotification(tt.prefs, missions, 130, fresh, false)
+		if sent != tt.wantSent {
+			t.Errorf("%s: sent = %v, want %v", tt.name, sent, tt.wantSent)
+			continue
+		}
+		if sent && !strings.Contains(text, tt.want) {
+			t.Errorf("%s: notification lacks %q:\n%s", tt.name, tt.want, text)
+		}
+	}
+}
+
+func TestRenderNotificationShortRepeat(t *testing.T) {
+	missions := []VBucksMission{{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"}}
+	full, _ := renderNotification(ChatPreferences{ShortRepeats: true}, missions, 80, Freshness{}, false)
+	repeat, _ := renderNotification(ChatPreferences{ShortRepeats: true}, missions, 80, Freshness{}, true)
+	if full == repeat {
+		t.Errorf("a repeat day got the full list with /shortrepeats on:\n%s", repeat)
+	}
+}
+
+func TestAddToAverage(t *testing.T) {
+	var p ChatPreferences
+	addToAverage(&p, "2024-03-01", 100)
+	addToAverage(&p, "2024-03-01", 1000) // same day again is ignored
+	addToAverage(&p, "2024-03-02", 200)
+	if p.AverageDays != 2 || p.AverageTotal != 150 {
+		t.Fatalf("after two days average = %v over %d days, want 150 over 2", p.AverageTotal, p.AverageDays)
+	}
+
+	// Past the window, the average keeps moving instead of freezing
+	for day := 3; day <= averageWindow+10; day++ {
+		addToAverage(&p, time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC).Format(dateLayout), 300)
+	}
+	if p.AverageDays != averageWindow {
+		t.Errorf("AverageDays = %d, want capped at %d", p.AverageDays, averageWindow)
+	}
+	if math.Abs(p.AverageTotal-300) > 30 {
+		t.Errorf("AverageTotal = %v, want close to 300 after many 300 days", p.AverageTotal)
+	}
+}
+
+func TestSetNotifyMode(t *testing.T) {
+	store := newTestStore(t)
+	for _, mode := range []string{notifySummary, notifyDigest, notifyFull} {
+		setNotifyMode(store, 1, strings.ToUpper(mode))
+		if got := store.get(1).NotifyMode; got != mode {
+			t.Errorf("/notifymode %s stored %q", mode, got)
+		}
+	}
+	if reply := setNotifyMode(store, 1, "loud"); !strings.HasPrefix(reply, "Usage:") {
+		t.Errorf("/notifymode loud = %q, want the usage", reply)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxPollOptions is the most options Telegram allows in a poll
+	maxPollOptions = 10
+	// maxPollOptionLength is the longest option text Telegram accepts
+	maxPollOptionLength = 100
+)
+
+// amountScore ranks missions by V-Bucks for rankMissions
+func amountScore(m VBucksMission) (float64, bool) {
+	amount, ok := m.amountValue()
+	return float64(amount), ok
+}
+
+// pollOptions returns the option texts for the best paying missions, at
+// most maxPollOptions of them
+func pollOptions(vbucksMissions []VBucksMission) []string {
+	var options []string
+	for _, r := range rankMissions(vbucksMissions, amountScore) {
+		if len(options) == maxPollOptions {
+			break
+		}
+		option := missionLine(r.mission)
+		if runes := []rune(option); len(runes) > maxPollOptionLength {
+			option = string(runes[:maxPollOptionLength-1]) + "…"
+		}
+		options = append(options, option)
+	}
+	return options
+}
+
+// sendMissionPoll handles /poll, asking the chat which of today's missions
+// they'll do
+// Polls need at least two options, so with fewer missions the normal list is
+// sent instead
+func sendMissionPoll(t *tenant, chatID int64) {
+	missions, _ := getMissions()
+	options := pollOptions(missions)
+	if len(options) < 2 {
+		showMissions(t, chatID, "")
+		return
+	}
+
+	poll := tgbotapi.NewPoll(chatID, "Which mission will you do?", options...)
+	poll.IsAnonymous = false
+	if _, err := t.bot.Send(poll); err != nil {
+		log.Printf("Error sending poll to chat %d: %v", chatID, err)
+		showMissions(t, chatID, "")
+	}
+}
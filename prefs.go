@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// ChatPreferences holds the settings a chat can change with bot commands
+type ChatPreferences struct {
+	// Area limits output to missions in matching areas; empty shows all areas
+	Area string
+
+	// MinPowerLevel and MaxPowerLevel hold the "pl>=" and "pl<=" filters;
+	// zero means no bound
+	MinPowerLevel int
+	MaxPowerLevel int
+
+	// WatchedTypes are mission types the chat wants a ping for when they appear
+	WatchedTypes []string
+
+	// HideTotal leaves the total line out of mission lists; the total is shown
+	// by default
+	HideTotal bool
+	// Compact shortens each mission line to its reward and area; lines are
+	// verbose by default
+	Compact bool `json:",omitempty"`
+
+	// Subscribed chats get the missions pushed to them after each daily reset
+	Subscribed bool
+	// WeekendsOnly limits the daily push to Saturdays and Sundays in the
+	// chat's time zone
+	WeekendsOnly bool
+	// LastNotified is the last day the daily push handled the chat, including
+	// days it skipped because nothing was due
+	LastNotified string `json:",omitempty"`
+
+	// ShortRepeats sends a short note instead of the full list when the day's
+	// missions are the same as yesterday's
+	ShortRepeats bool
+
+	// SeasonStart is the YYYY-MM-DD day /seasontotal sums from; empty for none
+	SeasonStart string `json:",omitempty"`
+
+	// Goal is the V-Bucks total the chat is working towards; zero means none
+	Goal int
+	// GoalEarned is how much of the goal the missions marked done add up to
+	GoalEarned int
+	// GoalDone maps the IDs of missions marked done to the date they were
+	// done, pruned to the history retention window
+	GoalDone map[string]string `json:",omitempty"`
+
+	// Aliases maps the chat's own command names to registered commands
+	Aliases map[string]string `json:",omitempty"`
+
+	// OnlyNew limits the daily push to missions the chat wasn't sent before
+	OnlyNew bool
+	// Seen maps the key of each mission pushed to the chat to the date it was
+	// last sent, pruned to the history retention window
+	Seen map[string]string `json:",omitempty"`
+
+	// NotifyMode is how the daily push is rendered: full (the default when
+	// empty), summary or digest, which is only sent on above-average days
+	NotifyMode string `json:",omitempty"`
+	// AverageTotal is the chat's rolling average of the V-Bucks its filters
+	// keep each day, over AverageDays days; AverageDate is the last day added
+	AverageTotal float64
+	AverageDays  int
+	AverageDate  string `json:",omitempty"`
+	// WorthThreshold is the total /worth calls worth playing for; zero
+	// compares with the average instead
+	WorthThreshold int
+	// MyPowerLevel is the player's power level set with /mypl; zero when unset
+	MyPowerLevel int `json:",omitempty"`
+
+	// Timezone is the IANA zone /remindat times are in; empty means UTC
+	Timezone string `json:",omitempty"`
+	// RemindAt is the local HH:MM of the chat's daily reminder; empty for none
+	RemindAt string `json:",omitempty"`
+	// LastReminded is the local date the daily reminder last fired
+	LastReminded string `json:",omitempty"`
+
+	// Keywords makes the bot answer plain messages containing a keyword, so
+	// groups can ask without slash commands
+	Keywords bool
+}
+
+// clone returns a copy of the preferences that shares no maps or slices
+// with p, so the copy can be changed outside the store's lock
+func (p ChatPreferences) clone() ChatPreferences {
+	p.WatchedTypes = append([]string(nil), p.WatchedTypes...)
+	p.GoalDone = copyStringMap(p.GoalDone)
+	p.Aliases = copyStringMap(p.Aliases)
+	p.Seen = copyStringMap(p.Seen)
+	return p
+}
+
+// copyStringMap copies m, keeping nil as nil
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// preferenceStore keeps every chat's preferences and persists them to a file
+type preferenceStore struct {
+	mu    sync.Mutex
+	path  string
+	chats map[int64]ChatPreferences
+}
+
+// loadPreferences reads stored chat preferences from path, if it exists
+func loadPreferences(path string) (*preferenceStore, error) {
+	store := &preferenceStore{path: path, chats: map[int64]ChatPreferences{}}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return store, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, &store.chats); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return store, nil
+}
+
+// get returns the chat's preferences, or the defaults if it has none stored
+func (s *preferenceStore) get(chatID int64) ChatPreferences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.chats[chatID].clone()
+}
+
+// update applies change to the chat's preferences and saves the store
+func (s *preferenceStore) update(chatID int64, change func(*ChatPreferences)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs := s.chats[chatID]
+	change(&prefs)
+	s.chats[chatID] = prefs
+
+	return s.save()
+}
+
+// reset drops the chat's preferences, restoring the defaults, and saves the store
+func (s *preferenceStore) reset(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.chats, chatID)
+	return s.save()
+}
+
+// all returns a copy of every chat's preferences
+func (s *preferenceStore) all() map[int64]ChatPreferences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := make(map[int64]ChatPreferences, len(s.chats))
+	for chatID, prefs := range s.chats {
+		chats[chatID] = prefs.clone()
+	}
+	return chats
+}
+
+// subscribers returns the IDs of all chats subscribed to daily notifications
+func (s *preferenceStore) subscribers() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chatIDs []int64
+	for chatID, prefs := range s.chats {
+		if prefs.Subscribed {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+	return chatIDs
+}
+
+// save writes the store to its file; the caller must hold the lock
+func (s *preferenceStore) save() error {
+	data, err := json.Marshal(s.chats)
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %v", err)
+	}
+
+	if err := ioutil.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", s.path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestStore returns an empty preference store saving to a temporary file
+func newTestStore(t *testing.T) *preferenceStore {
+	t.Helper()
+	store, err := loadPreferences(filepath.Join(t.TempDir(), "prefs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestGetReturnsIndependentCopy(t *testing.T) {
+	store := newTestStore(t)
+	err := store.update(1, func(p *ChatPreferences) {
+		p.Seen = map[string]string{"a": "2024-01-01"}
+		p.Aliases = map[string]string{"v": "vbucks"}
+		p.GoalDone = map[string]string{"x": "2024-01-01"}
+		p.WatchedTypes = []string{"Fight the Storm"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefs := store.get(1)
+	prefs.Seen["b"] = "2024-01-02"
+	prefs.Aliases["w"] = "worth"
+	prefs.GoalDone["y"] = "2024-01-02"
+	prefs.WatchedTypes[0] = "Ride the Lightning"
+
+	stored := store.get(1)
+	if len(stored.Seen) != 1 || len(stored.Aliases) != 1 || len(stored.GoalDone) != 1 {
+		t.Errorf("changing a copy changed the store: %+v", stored)
+	}
+	if stored.WatchedTypes[0] != "Fight the Storm" {
+		t.Errorf("WatchedTypes = %v, changing a copy changed the store", stored.WatchedTypes)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// prefsCodeVersion is the version of the /exportprefs format; bump it when
+// sharedPreferences changes in a way older codes need converting for
+const prefsCodeVersion = 1
+
+// maxWatchedTypes caps the watched mission types an imported code may set
+const maxWatchedTypes = 50
+
+// sharedPreferences are the settings /exportprefs carries over to another
+// chat; state such as seen missions, goal progress and averages stays behind
+type sharedPreferences struct {
+	Version int
+
+	Area          string            `json:",omitempty"`
+	MinPowerLevel int               `json:",omitempty"`
+	MaxPowerLevel int               `json:",omitempty"`
+	MyPowerLevel  int               `json:",omitempty"`
+	WatchedTypes  []string          `json:",omitempty"`
+	HideTotal     bool              `json:",omitempty"`
+	Compact       bool              `json:",omitempty"`
+	Subscribed    bool              `json:",omitempty"`
+	WeekendsOnly  bool              `json:",omitempty"`
+	ShortRepeats  bool              `json:",omitempty"`
+	OnlyNew       bool              `json:",omitempty"`
+	Keywords      bool              `json:",omitempty"`
+	NotifyMode    string            `json:",omitempty"`
+	Goal          int               `json:",omitempty"`
+	SeasonStart   string            `json:",omitempty"`
+	Worth         int               `json:",omitempty"`
+	Timezone      string            `json:",omitempty"`
+	RemindAt      string            `json:",omitempty"`
+	Aliases       map[string]string `json:",omitempty"`
+}
+
+// exportPreferences encodes the chat's shareable settings as a code for
+// /importprefs
+func exportPreferences(prefs ChatPreferences) (string, error) {
+	shared := sharedPreferences{
+		Version:       prefsCodeVersion,
+		Area:          prefs.Area,
+		MinPowerLevel: prefs.MinPowerLevel,
+		MaxPowerLevel: prefs.MaxPowerLevel,
+		MyPowerLevel:  prefs.MyPowerLevel,
+		WatchedTypes:  prefs.WatchedTypes,
+		HideTotal:     prefs.HideTotal,
+		Compact:       prefs.Compact,
+		Subscribed:    prefs.Subscribed,
+		WeekendsOnly:  prefs.WeekendsOnly,
+		ShortRepeats:  prefs.ShortRepeats,
+		OnlyNew:       prefs.OnlyNew,
+		Keywords:      prefs.Keywords,
+		NotifyMode:    prefs.NotifyMode,
+		Goal:          prefs.Goal,
+		SeasonStart:   prefs.SeasonStart,
+		Worth:         prefs.WorthThreshold,
+		Timezone:      prefs.Timezone,
+		RemindAt:      prefs.RemindAt,
+		Aliases:       prefs.Aliases,
+	}
+
+	encoded, err := json.Marshal(shared)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode preferences: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodePreferences parses and sanity-checks an /exportprefs code
+// Aliases are checked against the commands the chat can run
+func decodePreferences(code string, chatID int64) (sharedPreferences, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(code))
+	if err != nil {
+		return sharedPreferences{}, fmt.Errorf("that isn't a code from /exportprefs")
+	}
+
+	var shared sharedPreferences
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&shared); err != nil {
+		return sharedPreferences{}, fmt.Errorf("that isn't a code from /exportprefs")
+	}
+
+	switch {
+	case shared.Version > prefsCodeVersion:
+		return sharedPreferences{}, fmt.Errorf("that code is from a newer version of the bot")
+	case shared.Version < 1:
+		return sharedPreferences{}, fmt.Errorf("that isn't a code from /exportprefs")
+	}
+
+	if err := shared.validate(chatID); err != nil {
+		return sharedPreferences{}, err
+	}
+	return shared, nil
+}
+
+// validate rejects settings the bot's own commands wouldn't accept
+func (s sharedPreferences) validate(chatID int64) error {
+	for _, level := range []int{s.MinPowerLevel, s.MaxPowerLevel, s.MyPowerLevel} {
+		if level < 0 || level > maxPowerLevel {
+			return fmt.Errorf("power levels must be between 1 and %d", maxPowerLevel)
+		}
+	}
+	if s.MinPowerLevel > 0 && s.MaxPowerLevel > 0 && s.MinPowerLevel > s.MaxPowerLevel {
+		return fmt.Errorf("the power level filter's minimum is above its maximum")
+	}
+	if s.Goal < 0 || s.Worth < 0 {
+		return fmt.Errorf("the goal and /worth bar can't be negative")
+	}
+	if len(s.WatchedTypes) > maxWatchedTypes {
+		return fmt.Errorf("it watches more than %d mission types", maxWatchedTypes)
+	}
+
+	switch s.NotifyMode {
+	case "", notifyFull, notifySummary, notifyDigest:
+	default:
+		return fmt.Errorf("%q isn't a notification format", s.NotifyMode)
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("%q isn't a time zone I know", s.Timezone)
+		}
+	}
+	if s.SeasonStart != "" {
+		if _, err := time.Parse(dateLayout, s.SeasonStart); err != nil {
+			return fmt.Errorf("%q isn't a season start date", s.SeasonStart)
+		}
+	}
+	if s.RemindAt != "" {
+		if at, err := time.Parse(reminderLayout, s.RemindAt); err != nil || at.Format(reminderLayout) != s.RemindAt {
+			return fmt.Errorf("%q isn't a reminder time", s.RemindAt)
+		}
+	}
+
+	for name, target := range s.Aliases {
+		if !aliasNamePattern.MatchString(name) {
+			return fmt.Errorf("/%s isn't a valid alias name", name)
+		}
+		if _, exists := commands.lookup(name); exists {
+			return fmt.Errorf("the alias /%s is a command here", name)
+		}
+		cmd, ok := commands.lookup(target)
+		if !ok || (cmd.Spec().Role == roleAdmin && !isAdmin(chatID)) {
+			return fmt.Errorf("the alias /%s runs /%s, which isn't a command", name, target)
+		}
+	}
+	return nil
+}
+
+// apply copies the shared settings over the chat's, leaving its state alone
+func (s sharedPreferences) apply(p *ChatPreferences) {
+	p.Area = s.Area
+	p.MinPowerLevel = s.MinPowerLevel
+	p.MaxPowerLevel = s.MaxPowerLevel
+	p.MyPowerLevel = s.MyPowerLevel
+	p.WatchedTypes = append([]string(nil), s.WatchedTypes...)
+	p.HideTotal = s.HideTotal
+	p.Compact = s.Compact
+	p.Subscribed = s.Subscribed
+	p.WeekendsOnly = s.WeekendsOnly
+	p.ShortRepeats = s.ShortRepeats
+	p.OnlyNew = s.OnlyNew
+	p.Keywords = s.Keywords
+	p.NotifyMode = s.NotifyMode
+	p.Goal = s.Goal
+	p.SeasonStart = s.SeasonStart
+	p.WorthThreshold = s.Worth
+	p.Timezone = s.Timezone
+	p.RemindAt = s.RemindAt
+	p.Aliases = s.Aliases
+
+	// Don't fire straight away for a reminder time that's already passed
+	if local := time.Now().In(chatLocation(*p)); p.RemindAt != "" && local.Format(reminderLayout) >= p.RemindAt {
+		p.LastReminded = local.Format(dateLayout)
+	}
+}
+
+// handleExportPrefs handles /exportprefs
+func handleExportPrefs(store *preferenceStore, chatID int64) string {
+	code, err := exportPreferences(store.get(chatID))
+	if err != nil {
+		log.Printf("Error exporting preferences for chat %d: %v", chatID, err)
+		return "Sorry, your settings couldn't be exported. Please try again later."
+	}
+	return "Send this in another chat to copy your settings there:\n\n/importprefs " + code
+}
+
+// handleImportPrefs handles /importprefs <code>
+func handleImportPrefs(store *preferenceStore, chatID int64, args string) string {
+	if strings.TrimSpace(args) == "" {
+		return "Usage: /importprefs <code>, with a code from /exportprefs"
+	}
+
+	shared, err := decodePreferences(args, chatID)
+	if err != nil {
+		return fmt.Sprintf("Couldn't import those settings: %v.", err)
+	}
+
+	if err := store.update(chatID, shared.apply); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+	return "Settings imported. /settings shows them."
+}
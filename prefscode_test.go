@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// prefsCode encodes raw JSON the way /exportprefs does
+func prefsCode(payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+func TestPreferencesCodeRoundTrip(t *testing.T) {
+	prefs := ChatPreferences{
+		Area:          "Twine Peaks",
+		MinPowerLevel: 100,
+		MaxPowerLevel: 140,
+		WatchedTypes:  []string{"Ride the Lightning"},
+		Compact:       true,
+		Subscribed:    true,
+		NotifyMode:    notifyDigest,
+		Timezone:      "Europe/Lisbon",
+		Aliases:       map[string]string{"v": "vbucks"},
+		// State stays with the chat
+		Seen:       map[string]string{"abc": "2024-03-01"},
+		GoalEarned: 500,
+	}
+
+	code, err := exportPreferences(prefs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := decodePreferences(code, 1)
+	if err != nil {
+		t.Fatalf("decodePreferences rejected its own export: %v", err)
+	}
+
+	var imported ChatPreferences
+	shared.apply(&imported)
+	want := prefs
+	want.Seen, want.GoalEarned = nil, 0
+	if !reflect.DeepEqual(imported, want) {
+		t.Errorf("imported\n%+v\nwant\n%+v", imported, want)
+	}
+}
+
+func TestDecodePreferencesRejects(t *testing.T) {
+	withAdmin(t, 100)
+	tests := map[string]string{
+		"not base64":         "!!!",
+		"not JSON":           prefsCode(`hello`),
+		"unknown field":      prefsCode(`{"Version": 1, "Admin": true}`),
+		"no version":         prefsCode(`{"Area": "Twine Peaks"}`),
+		"newer version":      prefsCode(`{"Version": 99}`),
+		"power level":        prefsCode(`{"Version": 1, "MyPowerLevel": 1000}`),
+		"inverted filter":    prefsCode(`{"Version": 1, "MinPowerLevel": 140, "MaxPowerLevel": 100}`),
+		"negative goal":      prefsCode(`{"Version": 1, "Goal": -5}`),
+		"notify mode":        prefsCode(`{"Version": 1, "NotifyMode": "loud"}`),
+		"time zone":          prefsCode(`{"Version": 1, "Timezone": "Mars/Olympus"}`),
+		"season start":       prefsCode(`{"Version": 1, "SeasonStart": "March"}`),
+		"reminder time":      prefsCode(`{"Version": 1, "RemindAt": "9:5"}`),
+		"alias name":         prefsCode(`{"Version": 1, "Aliases": {"Bad Name": "vbucks"}}`),
+		"alias shadows":      prefsCode(`{"Version": 1, "Aliases": {"help": "vbucks"}}`),
+		"alias to nothing":   prefsCode(`{"Version": 1, "Aliases": {"x": "nope"}}`),
+		"alias to admin cmd": prefsCode(`{"Version": 1, "Aliases": {"d": "deadletters"}}`),
+		"too many types":     prefsCode(`{"Version": 1, "WatchedTypes": [` + strings.Repeat(`"a",`, maxWatchedTypes) + `"a"]}`),
+	}
+	for name, code := range tests {
+		if _, err := decodePreferences(code, 1); err == nil {
+			t.Errorf("%s: decodePreferences accepted the code", name)
+		}
+	}
+
+	// The admin chat may alias admin commands
+	if _, err := decodePreferences(prefsCode(`{"Version": 1, "Aliases": {"d": "deadletters"}}`), 100); err != nil {
+		t.Errorf("admin alias to /deadletters rejected: %v", err)
+	}
+}
+
+func TestHandleImportPrefsKeepsSettingsOnError(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.update(1, func(p *ChatPreferences) { p.Area = "Plankerton" }); err != nil {
+		t.Fatal(err)
+	}
+
+	got := handleImportPrefs(store, 1, prefsCode(`{"Version": 1, "Area": "Twine Peaks", "NotifyMode": "loud"}`))
+	if !strings.HasPrefix(got, "Couldn't import those settings") {
+		t.Errorf("/importprefs with a bad code = %q", got)
+	}
+	if area := store.get(1).Area; area != "Plankerton" {
+		t.Errorf("Area = %q after a rejected import, want it unchanged", area)
+	}
+
+	if got := handleImportPrefs(store, 1, prefsCode(`{"Version": 1, "Area": "Twine Peaks"}`)); got != "Settings imported. /settings shows them." {
+		t.Errorf("/importprefs = %q", got)
+	}
+	if area := store.get(1).Area; area != "Twine Peaks" {
+		t.Errorf("Area = %q after the import, want Twine Peaks", area)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// projectionDays is how far back /projection looks
+const projectionDays = 7
+
+// formatProjection sums the V-Bucks offered over the last projectionDays days
+// of history and projects a weekly total from the daily average
+func formatProjection(h *historyStore, now time.Time) string {
+	oldest := now.UTC().AddDate(0, 0, -(projectionDays - 1)).Format(dateLayout)
+
+	var days, total, estimated int
+	for _, date := range h.dates() {
+		if date < oldest {
+			continue
+		}
+		missions, _ := h.missionsOn(date)
+		sum, est := sumVBucks(missions)
+		total += sum
+		estimated += est
+		days++
+	}
+
+	if days == 0 {
+		return "No mission history yet, so there's nothing to project from. Check back after a day of scraping."
+	}
+
+	average := float64(total) / float64(days)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "V-Bucks offered over the last %d days: %d%s\n", days, total, estimateNote(estimated))
+	fmt.Fprintf(&b, "Daily average: %.1f\n", average)
+	fmt.Fprintf(&b, "Projected weekly total: ~%d\n", int(math.Round(average*7)))
+	if days < projectionDays {
+		fmt.Fprintf(&b, "\nOnly %d of %d days of history are available, so the projection is rough.", days, projectionDays)
+	}
+	b.WriteString("\nThis is based on past availability, not a guarantee of what's coming.")
+
+	return b.String()
+}
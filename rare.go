@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// rareShown is how many mission types /rare lists
+	rareShown = 5
+	// rareMinDays is how many days of history /rare wants before its ranking
+	// means much
+	rareMinDays = 7
+)
+
+// typeSighting is how often a mission type was offered within the history
+type typeSighting struct {
+	Type     string
+	Days     int
+	LastSeen string
+}
+
+// formatRare handles /rare: the mission types offered on the fewest days of
+// the retained history, with when each was last seen
+func formatRare(h *historyStore, now time.Time) string {
+	oldest := h.oldestDate(now)
+
+	sightings := map[string]*typeSighting{}
+	recorded := 0
+	for _, date := range h.dates() {
+		if date < oldest {
+			continue
+		}
+		recorded++
+
+		missions, _ := h.missionsOn(date)
+		counted := map[string]bool{}
+		for _, mission := range missions {
+			key := strings.ToLower(mission.MissionType)
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+
+			s, ok := sightings[key]
+			if !ok {
+				s = &typeSighting{Type: mission.MissionType}
+				sightings[key] = s
+			}
+			s.Days++
+			// Dates come oldest first, so the last one wins
+			s.LastSeen = date
+		}
+	}
+
+	if len(sightings) == 0 {
+		return "No mission history yet. Check back after a day of scraping."
+	}
+
+	ranked := make([]*typeSighting, 0, len(sightings))
+	for _, s := range sightings {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Days != ranked[j].Days {
+			return ranked[i].Days < ranked[j].Days
+		}
+		// Among equally rare types, the one gone longest comes first
+		if ranked[i].LastSeen != ranked[j].LastSeen {
+			return ranked[i].LastSeen < ranked[j].LastSeen
+		}
+		return ranked[i].Type < ranked[j].Type
+	})
+	if len(ranked) > rareShown {
+		ranked = ranked[:rareShown]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rarest V-Bucks mission types over %s of history:\n", plural(recorded, "day"))
+	for i, s := range ranked {
+		fmt.Fprintf(&b, "\n%d. %s: %s, last seen %s", i+1, s.Type, plural(s.Days, "day"), s.LastSeen)
+	}
+	if recorded < rareMinDays {
+		fmt.Fprintf(&b, "\n\nOnly %s of history so far, so this ranking is rough.", plural(recorded, "day"))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rateBudgetMinutes is the playtime /rate plans the best hour for
+const rateBudgetMinutes = 60
+
+// formatRate handles /rate: the most V-Bucks an hour of play gets today,
+// picking missions greedily by V-Bucks per minute until the hour is full,
+// and the hourly rate of playing every mission with a time estimate
+func formatRate(vbucksMissions []VBucksMission) string {
+	if len(vbucksMissions) == 0 {
+		return "No V-Bucks missions found today."
+	}
+
+	var picked []VBucksMission
+	var pickedMinutes, pickedVBucks, allMinutes, allVBucks, skipped int
+	for _, r := range rankMissions(vbucksMissions, vbucksPerMinute) {
+		if !r.scored {
+			skipped++
+			continue
+		}
+		minutes, _ := estimatedMinutes(r.mission.MissionType)
+		amount, _ := r.mission.amountValue()
+		allMinutes += minutes
+		allVBucks += amount
+
+		if pickedMinutes+minutes <= rateBudgetMinutes {
+			picked = append(picked, r.mission)
+			pickedMinutes += minutes
+			pickedVBucks += amount
+		}
+	}
+
+	if allMinutes == 0 {
+		return "None of today's missions have a time estimate, so there's no rate to work out. See /time"
+	}
+
+	var result strings.Builder
+	if len(picked) == 0 {
+		result.WriteString("Every mission today takes more than an hour.\n")
+	} else {
+		result.WriteString(fmt.Sprintf("Best hour today: %d V-Bucks from %s (~%d min):\n", pickedVBucks, plural(len(picked), "mission"), pickedMinutes))
+		for i, mission := range picked {
+			result.WriteString(fmt.Sprintf("%d. %s\n", i+1, missionLine(mission)))
+		}
+	}
+	result.WriteString(fmt.Sprintf("\nAll %s with a time estimate: %d V-Bucks in ~%d min, about %.0f V-Bucks per hour.",
+		plural(len(vbucksMissions)-skipped, "mission"), allVBucks, allMinutes, float64(allVBucks)*60/float64(allMinutes)))
+	if skipped > 0 {
+		result.WriteString(fmt.Sprintf("\nLeft out %s without a time estimate or amount.", plural(skipped, "mission")))
+	}
+	result.WriteString("\n\nAssumes the /time estimates for a public squad, each mission played once, and no time between missions.")
+
+	return result.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultRawHTMLPath is where the last scraped page is kept by default
+	defaultRawHTMLPath = "last_scrape.html"
+	// maxRawHTMLBytes caps the saved page; anything beyond is dropped
+	maxRawHTMLBytes = 2 << 20
+)
+
+// rawHTMLPath is where the last scraped page is saved, set from RAW_HTML_PATH
+// An empty path turns saving off
+var rawHTMLPath = defaultRawHTMLPath
+
+// loadRawHTMLPath reads RAW_HTML_PATH, keeping the default when unset
+func loadRawHTMLPath() {
+	if v, ok := os.LookupEnv("RAW_HTML_PATH"); ok {
+		rawHTMLPath = strings.TrimSpace(v)
+	}
+}
+
+// rawHTMLPathFor returns the file a source's page is saved to; with several
+// sources the source name is added so they don't overwrite each other
+func rawHTMLPathFor(src Source) string {
+	if len(sources) <= 1 {
+		return rawHTMLPath
+	}
+	ext := filepath.Ext(rawHTMLPath)
+	return strings.TrimSuffix(rawHTMLPath, ext) + "." + src.Name + ext
+}
+
+// saveRawHTML keeps the scraped page on disk so the parser can be re-run
+// against it offline
+func saveRawHTML(src Source, body []byte) {
+	if rawHTMLPath == "" || len(body) == 0 {
+		return
+	}
+
+	if len(body) > maxRawHTMLBytes {
+		log.Printf("Scraped page from %s is %d bytes, saving only the first %d", src.Name, len(body), maxRawHTMLBytes)
+		body = body[:maxRawHTMLBytes]
+	}
+
+	if err := ioutil.WriteFile(rawHTMLPathFor(src), body, 0644); err != nil {
+		log.Printf("Error saving scraped page: %v", err)
+	}
+}
+
+// parseSavedHTML re-runs the parser on a saved page and prints the missions
+// Used by the parse-saved-html subcommand
+func parseSavedHTML(path string) ([]VBucksMission, error) {
+	if path == "" {
+		path = rawHTMLPath
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no page to parse: pass a path or set RAW_HTML_PATH")
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved page: %v", err)
+	}
+
+	missions, err := parseMissionsHTML(toUTF8(path, body, ""))
+	if err != nil {
+		return nil, err
+	}
+	// Same as a live scrape, so the output matches what would be cached
+	normalizeAmounts(path, missions)
+
+	fmt.Printf("Parsed %d missions from %s:\n", len(missions), path)
+	printMissions(missions)
+
+	return missions, nil
+}
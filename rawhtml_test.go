@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestParseSavedHTMLNormalizesAmounts(t *testing.T) {
+	missions, err := parseSavedHTML("testdata/timed-missions.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var amounts []string
+	for _, mission := range missions {
+		amounts = append(amounts, mission.Amount)
+	}
+	want := []string{"80", "1000", "50"}
+	if len(amounts) != len(want) {
+		t.Fatalf("amounts = %q, want %q", amounts, want)
+	}
+	for i := range want {
+		if amounts[i] != want[i] {
+			t.Errorf("amounts = %q, want %q", amounts, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshAllCooldown is the least time between two /refreshall runs, so a
+// double tap doesn't notify everyone twice
+const refreshAllCooldown = 10 * time.Minute
+
+// lastRefreshAll is when /refreshall last ran
+var lastRefreshAll struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+// refreshAll handles /refreshall: scrape now and, if the missions changed,
+// send them to every subscriber of every bot straight away
+func refreshAll() string {
+	lastRefreshAll.mu.Lock()
+	if wait := refreshAllCooldown - time.Since(lastRefreshAll.at); wait > 0 {
+		lastRefreshAll.mu.Unlock()
+		return fmt.Sprintf("/refreshall ran less than %s ago, try again in %s.", refreshAllCooldown, formatAge(wait))
+	}
+	// Claim the run now so a double tap waits, but give it back if the scrape
+	// fails so the admin can retry straight away
+	previousRun, started := lastRefreshAll.at, time.Now()
+	lastRefreshAll.at = started
+	lastRefreshAll.mu.Unlock()
+
+	previous, current, err := refreshMissions()
+	if err != nil {
+		lastRefreshAll.mu.Lock()
+		if lastRefreshAll.at.Equal(started) {
+			lastRefreshAll.at = previousRun
+		}
+		lastRefreshAll.mu.Unlock()
+		return "Refresh failed: " + err.Error()
+	}
+
+	added, removed := diffMissions(previous, current)
+	if len(added) == 0 && len(removed) == 0 {
+		return fmt.Sprintf("Scraped %d missions, nothing changed, so nobody was notified.", len(current))
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Scraped %d missions: %d added, %d removed. Notifying subscribers:\n", len(current), len(added), len(removed)))
+	for _, t := range runningTenants() {
+		sent := broadcastMissions(t, current, Freshness{UpdatedAt: time.Now().UTC()})
+		result.WriteString(fmt.Sprintf("@%s: delivered to %d of %d, %d queued for retry\n",
+			t.bot.Self.UserName, sent.Delivered, sent.Subscribers, sent.Retrying))
+	}
+
+	return strings.TrimSuffix(result.String(), "\n")
+}
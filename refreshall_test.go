@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshAllCooldownStartsOnSuccess(t *testing.T) {
+	inTempDir(t)
+	lastRefreshAll.mu.Lock()
+	saved := lastRefreshAll.at
+	lastRefreshAll.at = time.Time{}
+	lastRefreshAll.mu.Unlock()
+	t.Cleanup(func() {
+		lastRefreshAll.mu.Lock()
+		lastRefreshAll.at = saved
+		lastRefreshAll.mu.Unlock()
+	})
+
+	var failing int32 = 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, `[{"Area": "Twine Peaks", "PowerLevel": "140", "Amount": "80", "MissionType": "Ride the Lightning"}]`)
+	}))
+	defer srv.Close()
+	withSources(t, Source{Name: "api", Kind: SourceKindJSON, URL: srv.URL})
+
+	// A failing source can be retried straight away
+	for i := 0; i < 2; i++ {
+		if got := refreshAll(); !strings.HasPrefix(got, "Refresh failed") {
+			t.Fatalf("refresh %d against a failing source = %q", i+1, got)
+		}
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	if got := refreshAll(); !strings.HasPrefix(got, "Scraped 1 missions") {
+		t.Fatalf("refresh once the source is back = %q", got)
+	}
+	if got := refreshAll(); !strings.Contains(got, "try again in") {
+		t.Errorf("refresh right after a successful one = %q, want the cooldown", got)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/joho/godotenv"
+)
+
+// configMu guards the settings a reload can change that are read outside of
+// scraping; the ones only scraping reads are swapped under missionsMu
+var configMu sync.RWMutex
+
+// restartOnlySettings are read once at startup, so changing them needs a restart
+var restartOnlySettings = map[string]bool{
+	"TELEGRAM_BOT_TOKEN":  true,
+	"TELEGRAM_BOT_TOKENS": true,
+	"HTTP_ADDR":           true,
+	"HISTORY_DAYS":        true,
+	"WARM_CACHE":          true,
+	"CATCH_UP":            true,
+	"REPLICA":             true,
+	"REPLICA_URL":         true,
+	"STATSD_ADDR":         true,
+	"CALLBACK_URL":        true,
+	"CALLBACK_SECRET":     true,
+	"TTS_URL":             true,
+	"TTS_TOKEN":           true,
+}
+
+// settingNames lists the settings in defaultEnv
+func settingNames() []string {
+	var names []string
+	for _, line := range strings.Split(defaultEnv, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, "="); i > 0 {
+			names = append(names, line[:i])
+		}
+	}
+	return names
+}
+
+// currentSettings returns the value of every setting in the environment
+func currentSettings() map[string]string {
+	values := map[string]string{}
+	for _, name := range settingNames() {
+		values[name] = os.Getenv(name)
+	}
+	return values
+}
+
+// environmentSnapshot returns the settings set in the environment, so a
+// failed reload can put them back with restoreEnvironment
+func environmentSnapshot() map[string]string {
+	values := map[string]string{}
+	for _, name := range settingNames() {
+		if v, ok := os.LookupEnv(name); ok {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// restoreEnvironment sets the settings back to a snapshot, unsetting the ones
+// that weren't set then
+func restoreEnvironment(values map[string]string) {
+	for _, name := range settingNames() {
+		if v, ok := values[name]; ok {
+			os.Setenv(name, v)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+// settingsSnapshot holds the reloadable settings so a failed reload can be
+// rolled back
+type settingsSnapshot struct {
+	cacheCompress     bool
+	headlessFallback  bool
+	welcomeMessage    string
+	welcomeMarkdown   bool
+	fallbackMessage   string
+	fixtureMissions   []VBucksMission
+	fixtureMode       bool
+	watchKeep         bool
+	areaAliases       map[string]string
+	missionMinutes    map[string]int
+	sources           []Source
+	baseCollector     *colly.Collector
+	sourceTimeout     time.Duration
+	rawHTMLPath       string
+	adminChatID       int64
+	adminToken        string
+	healthMaxStale    time.Duration
+	healthMaxFailures int
+	keywords          []string
+	keywordCooldown   time.Duration
+	worthThreshold    int
+	powerLevelMargin  int
+	areaChannels      map[string]int64
+	cacheWriteRetries int
+	cacheWriteDelay   time.Duration
+}
+
+// snapshotSettings captures the reloadable settings in use
+func snapshotSettings() settingsSnapshot {
+	return settingsSnapshot{
+		cacheCompress:     cacheCompress,
+		headlessFallback:  headlessFallback,
+		welcomeMessage:    welcomeMessage,
+		welcomeMarkdown:   welcomeMarkdown,
+		fallbackMessage:   fallbackMessage,
+		fixtureMissions:   fixtureMissions,
+		fixtureMode:       fixtureMode,
+		watchKeep:         watchKeep,
+		areaAliases:       areaAliases,
+		missionMinutes:    missionMinutes,
+		sources:           sources,
+		baseCollector:     baseCollector,
+		sourceTimeout:     sourceTimeout,
+		rawHTMLPath:       rawHTMLPath,
+		adminChatID:       adminChatID,
+		adminToken:        adminToken,
+		healthMaxStale:    healthMaxStale,
+		healthMaxFailures: healthMaxFailures,
+		keywords:          keywords,
+		keywordCooldown:   keywordCooldown,
+		worthThreshold:    worthThreshold,
+		powerLevelMargin:  powerLevelMargin,
+		areaChannels:      areaChannels,
+		cacheWriteRetries: cacheWriteRetries,
+		cacheWriteDelay:   cacheWriteDelay,
+	}
+}
+
+// restore puts the captured settings back in place
+func (s settingsSnapshot) restore() {
+	cacheCompress = s.cacheCompress
+	headlessFallback = s.headlessFallback
+	welcomeMessage = s.welcomeMessage
+	welcomeMarkdown = s.welcomeMarkdown
+	fallbackMessage = s.fallbackMessage
+	fixtureMissions = s.fixtureMissions
+	fixtureMode = s.fixtureMode
+	watchKeep = s.watchKeep
+	areaAliases = s.areaAliases
+	missionMinutes = s.missionMinutes
+	sources = s.sources
+	baseCollector = s.baseCollector
+	sourceTimeout = s.sourceTimeout
+	rawHTMLPath = s.rawHTMLPath
+	adminChatID = s.adminChatID
+	adminToken = s.adminToken
+	healthMaxStale = s.healthMaxStale
+	healthMaxFailures = s.healthMaxFailures
+	keywords = s.keywords
+	keywordCooldown = s.keywordCooldown
+	worthThreshold = s.worthThreshold
+	powerLevelMargin = s.powerLevelMargin
+	areaChannels = s.areaChannels
+	cacheWriteRetries = s.cacheWriteRetries
+	cacheWriteDelay = s.cacheWriteDelay
+}
+
+// watchReload reloads the configuration every time the process gets SIGHUP
+func watchReload() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		log.Print("SIGHUP received, reloading configuration")
+		if err := reloadConfig(); err != nil {
+			log.Printf("Error reloading configuration, keeping the current one: %v", err)
+		}
+	}
+}
+
+// reloadConfig re-reads .env and the config files and applies the settings
+// that can change while running, logging what changed
+func reloadConfig() error {
+	before := currentSettings()
+	env := environmentSnapshot()
+	if err := godotenv.Overload(envFile); err != nil {
+		restoreEnvironment(env)
+		return fmt.Errorf("failed to read %s: %v", envFile, err)
+	}
+	after := currentSettings()
+
+	// Hold off scrapes and readers while the settings are swapped
+	missionsMu.Lock()
+	defer missionsMu.Unlock()
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	snapshot := snapshotSettings()
+	if err := loadSettings(); err != nil {
+		// Readers of the environment shouldn't see the rejected values either
+		restoreEnvironment(env)
+		snapshot.restore()
+		return err
+	}
+
+	changed := 0
+	for _, name := range settingNames() {
+		if before[name] == after[name] {
+			continue
+		}
+		changed++
+		if restartOnlySettings[name] {
+			log.Printf("%s changed but only takes effect after a restart", name)
+		} else {
+			log.Printf("%s changed and was applied", name)
+		}
+	}
+	log.Printf("Configuration reloaded: %d settings changed in %s, %d sources configured", changed, envFile, len(sources))
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// inTempDir runs the test from an empty directory, so the config files it
+// reads and writes don't touch the repo
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestFailedReloadRestoresEnvironment(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("SOURCE_TIMEOUT", "45s")
+	t.Setenv("SCRAPE_DELAY", "")
+	os.Unsetenv("SCRAPE_DELAY")
+
+	// SOURCE_TIMEOUT=0s is rejected by loadSettings
+	if err := ioutil.WriteFile(envFile, []byte("SCRAPE_DELAY=5s\nSOURCE_TIMEOUT=0s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfig(); err == nil {
+		t.Fatal("reloadConfig accepted SOURCE_TIMEOUT=0s")
+	}
+
+	if v := os.Getenv("SOURCE_TIMEOUT"); v != "45s" {
+		t.Errorf("SOURCE_TIMEOUT = %q after the failed reload, want 45s", v)
+	}
+	if v, ok := os.LookupEnv("SCRAPE_DELAY"); ok {
+		t.Errorf("SCRAPE_DELAY = %q after the failed reload, want it unset", v)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	// Embed the zone database so /timezone works on hosts without one
+	_ "time/tzdata"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// reminderCheckInterval is how often the daily reminders are checked
+const reminderCheckInterval = time.Minute
+
+// reminderLayout is how /remindat times are written
+const reminderLayout = "15:04"
+
+// chatLocation returns the chat's time zone, UTC when it has none or an
+// unknown one
+func chatLocation(prefs ChatPreferences) *time.Location {
+	if prefs.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// reminderDue reports whether the chat's daily reminder should fire at now,
+// in the chat's time zone, and the local date it fires for
+// Reminders fire once per local day, at or after the chosen time, so a check
+// that runs late or follows a time zone change still sends it
+func reminderDue(prefs ChatPreferences, now time.Time) (string, bool) {
+	if prefs.RemindAt == "" {
+		return "", false
+	}
+
+	local := now.In(chatLocation(prefs))
+	date := local.Format(dateLayout)
+	if prefs.LastReminded == date || local.Format(reminderLayout) < prefs.RemindAt {
+		return "", false
+	}
+	return date, true
+}
+
+// runReminders sends every chat with a /remindat time its daily nudge
+func runReminders(t *tenant) {
+	for range time.Tick(reminderCheckInterval) {
+		now := time.Now()
+		for chatID, prefs := range t.prefs.all() {
+			date, ok := reminderDue(prefs, now)
+			if !ok {
+				continue
+			}
+
+			missions, _ := getMissions()
+			err := send(t.bot, tgbotapi.NewMessage(chatID, "⏰ Time to check the missions! "+formatMissionsSummary(prefs, missions)))
+
+			// Blocked chats have nobody left to remind
+			blocked := err != nil && isBlocked(err)
+			if err := t.prefs.update(chatID, func(p *ChatPreferences) {
+				p.LastReminded = date
+				if blocked {
+					p.RemindAt = ""
+				}
+			}); err != nil {
+				log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+			}
+		}
+	}
+}
+
+// setRemindAt handles /remindat HH:MM|off
+// A time already passed today starts the reminder tomorrow
+func setRemindAt(store *preferenceStore, chatID int64, args string) string {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	if arg == "off" {
+		if err := store.update(chatID, func(p *ChatPreferences) { p.RemindAt = "" }); err != nil {
+			log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+			return "Sorry, your preference couldn't be saved. Please try again later."
+		}
+		return "Daily reminder turned off."
+	}
+
+	at, err := time.Parse(reminderLayout, arg)
+	if err != nil {
+		return "Usage: /remindat HH:MM (24-hour, in your /timezone), or /remindat off"
+	}
+	remindAt := at.Format(reminderLayout)
+
+	var zone string
+	err = store.update(chatID, func(p *ChatPreferences) {
+		p.RemindAt = remindAt
+		local := time.Now().In(chatLocation(*p))
+		zone = local.Location().String()
+		if local.Format(reminderLayout) >= remindAt {
+			p.LastReminded = local.Format(dateLayout)
+		}
+	})
+	if err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	return fmt.Sprintf("I'll remind you to check the missions every day at %s %s. Use /timezone to change the time zone.", remindAt, zone)
+}
+
+// setTimezone handles /timezone <zone>, e.g. /timezone Europe/Lisbon
+func setTimezone(store *preferenceStore, chatID int64, args string) string {
+	name := strings.TrimSpace(args)
+	if name == "" {
+		return fmt.Sprintf("Your time zone is %s. Usage: /timezone <zone>, e.g. /timezone Europe/Lisbon", chatLocation(store.get(chatID)))
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Sprintf("%q isn't a time zone I know. Use a name like Europe/Lisbon or America/New_York.", name)
+	}
+
+	err = store.update(chatID, func(p *ChatPreferences) {
+		p.Timezone = loc.String()
+		// Don't fire straight away for a time that's already passed in the new zone
+		if local := time.Now().In(loc); p.RemindAt != "" && local.Format(reminderLayout) >= p.RemindAt {
+			p.LastReminded = local.Format(dateLayout)
+		}
+	})
+	if err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+	return fmt.Sprintf("Time zone set to %s, it's %s there now.", loc, time.Now().In(loc).Format(reminderLayout))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReminderDue(t *testing.T) {
+	// 07:30 UTC is 08:30 in Berlin and 20:30 in Auckland
+	now := time.Date(2024, 3, 6, 7, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		prefs    ChatPreferences
+		wantDate string
+		wantDue  bool
+	}{
+		{name: "no reminder", prefs: ChatPreferences{}},
+		{name: "before the time", prefs: ChatPreferences{RemindAt: "08:00"}},
+		{name: "at the time", prefs: ChatPreferences{RemindAt: "07:30"}, wantDate: "2024-03-06", wantDue: true},
+		{name: "late check", prefs: ChatPreferences{RemindAt: "06:00"}, wantDate: "2024-03-06", wantDue: true},
+		{name: "already sent today", prefs: ChatPreferences{RemindAt: "06:00", LastReminded: "2024-03-06"}},
+		{name: "time zone", prefs: ChatPreferences{RemindAt: "08:00", Timezone: "Europe/Berlin"}, wantDate: "2024-03-06", wantDue: true},
+		// Still morning in UTC, but evening in Auckland
+		{name: "local time", prefs: ChatPreferences{RemindAt: "20:00", Timezone: "Pacific/Auckland", LastReminded: "2024-03-05"}, wantDate: "2024-03-06", wantDue: true},
+		{name: "unknown zone falls back to UTC", prefs: ChatPreferences{RemindAt: "07:00", Timezone: "Mars/Olympus"}, wantDate: "2024-03-06", wantDue: true},
+	}
+	for _, tt := range tests {
+		date, due := reminderDue(tt.prefs, now)
+		if due != tt.wantDue || (due && date != tt.wantDate) {
+			t.Errorf("%s: reminderDue = %q, %v, want %q, %v", tt.name, date, due, tt.wantDate, tt.wantDue)
+		}
+	}
+}
+
+func TestSetRemindAt(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, bad := range []string{"", "25:00", "7am", "12:60"} {
+		if got := setRemindAt(store, 1, bad); !strings.HasPrefix(got, "Usage:") {
+			t.Errorf("/remindat %q = %q, want the usage", bad, got)
+		}
+	}
+	if store.get(1).RemindAt != "" {
+		t.Fatal("an invalid /remindat saved a reminder")
+	}
+
+	if got := setRemindAt(store, 1, "9:05"); !strings.Contains(got, "every day at 09:05 UTC") {
+		t.Errorf("/remindat 9:05 = %q", got)
+	}
+	if at := store.get(1).RemindAt; at != "09:05" {
+		t.Errorf("RemindAt = %q, want 09:05", at)
+	}
+
+	// A time already passed today doesn't fire until tomorrow
+	if now := time.Now().UTC(); now.Hour() > 0 || now.Minute() > 0 {
+		passed := now.Add(-time.Minute).Format(reminderLayout)
+		setRemindAt(store, 2, passed)
+		if _, due := reminderDue(store.get(2), now); due {
+			t.Errorf("/remindat %s fired straight away", passed)
+		}
+	}
+
+	setRemindAt(store, 1, "off")
+	if store.get(1).RemindAt != "" {
+		t.Error("/remindat off kept the reminder")
+	}
+}
+
+func TestSetTimezone(t *testing.T) {
+	store := newTestStore(t)
+	if got := setTimezone(store, 1, "Mars/Olympus"); !strings.Contains(got, "isn't a time zone") {
+		t.Errorf("/timezone Mars/Olympus = %q", got)
+	}
+	if got := setTimezone(store, 1, "Europe/Lisbon"); !strings.HasPrefix(got, "Time zone set to Europe/Lisbon") {
+		t.Errorf("/timezone Europe/Lisbon = %q", got)
+	}
+	if tz := store.get(1).Timezone; tz != "Europe/Lisbon" {
+		t.Errorf("Timezone = %q, want Europe/Lisbon", tz)
+	}
+	if got := setTimezone(store, 1, ""); !strings.HasPrefix(got, "Your time zone is Europe/Lisbon") {
+		t.Errorf("/timezone = %q", got)
+	}
+}
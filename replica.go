@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// replicaRefresh is how long a replica reuses the missions it got from the
+// primary's /missions before asking again
+const replicaRefresh = time.Minute
+
+var (
+	// replicaMode serves the HTTP API from the primary's data, without the
+	// bot or the scraper
+	replicaMode bool
+	// replicaURL is the primary's /missions endpoint; empty reads the shared
+	// cache file instead
+	replicaURL string
+)
+
+// loadReplicaConfig reads REPLICA and REPLICA_URL
+func loadReplicaConfig() {
+	replicaMode = os.Getenv("REPLICA") == "1"
+	replicaURL = os.Getenv("REPLICA_URL")
+}
+
+// replicaSourceName describes where a replica gets its missions, for logging
+func replicaSourceName() string {
+	if replicaURL != "" {
+		return replicaURL
+	}
+	return cacheFile
+}
+
+// missionsResponse is the body of GET /missions
+type missionsResponse struct {
+	UpdatedAt time.Time
+	Stale     bool
+	Missions  []VBucksMission
+}
+
+// replicaCache holds the last response fetched from the primary
+var replicaCache struct {
+	mu        sync.Mutex
+	fetchedAt time.Time
+	response  missionsResponse
+}
+
+// replicaMissions returns the missions a replica serves: read from the
+// shared cache file, or fetched from the primary when REPLICA_URL is set
+func replicaMissions() (missionsResponse, error) {
+	if replicaURL == "" {
+		cachedData, valid := loadFromCache()
+		if cachedData.Timestamp.IsZero() {
+			return missionsResponse{}, fmt.Errorf("no cache file written by the primary yet")
+		}
+		return missionsResponse{UpdatedAt: cachedData.Timestamp, Stale: !valid, Missions: cachedData.VBucksMissions}, nil
+	}
+
+	replicaCache.mu.Lock()
+	defer replicaCache.mu.Unlock()
+
+	if time.Since(replicaCache.fetchedAt) < replicaRefresh {
+		return replicaCache.response, nil
+	}
+
+	resp, err := jsonClient.Get(replicaURL)
+	if err != nil {
+		return missionsResponse{}, fmt.Errorf("failed to reach the primary: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return missionsResponse{}, fmt.Errorf("primary answered %s", resp.Status)
+	}
+
+	var response missionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return missionsResponse{}, fmt.Errorf("failed to decode the primary's missions: %v", err)
+	}
+
+	replicaCache.fetchedAt = time.Now()
+	replicaCache.response = response
+	return response, nil
+}
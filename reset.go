@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxPageResetAhead bounds how far ahead a reset read off a page may be;
+// anything further is a misread
+const maxPageResetAhead = 25 * time.Hour
+
+var (
+	// resetCountdownPattern matches a countdown like "Missions reset in 5h 23m"
+	resetCountdownPattern = regexp.MustCompile(`(?i)reset\w*\s+in\s*:?\s*(?:(\d+)\s*h\w*)?\s*(?:(\d+)\s*m\w*)?`)
+	// resetClockPattern matches a stated time like "Next reset: 00:00 UTC"
+	resetClockPattern = regexp.MustCompile(`(?i)reset\w*\s*(?:at|:)?\s*(\d{1,2}):(\d{2})\s*UTC`)
+)
+
+// parseResetTime looks for the next mission reset stated on a source page
+// Returns false when the page doesn't state one
+func parseResetTime(body []byte, now time.Time) (time.Time, bool) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return time.Time{}, false
+	}
+	text := doc.Text()
+	now = now.UTC()
+
+	var reset time.Time
+	if match := resetCountdownPattern.FindStringSubmatch(text); match != nil && (match[1] != "" || match[2] != "") {
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		reset = now.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute)
+	} else if match := resetClockPattern.FindStringSubmatch(text); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+		if hour > 23 || minute > 59 {
+			return time.Time{}, false
+		}
+		reset = time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.UTC)
+		if !reset.After(now) {
+			reset = reset.AddDate(0, 0, 1)
+		}
+	} else {
+		return time.Time{}, false
+	}
+
+	if !reset.After(now) || reset.Sub(now) > maxPageResetAhead {
+		return time.Time{}, false
+	}
+	return reset, true
+}
+
+// formatWhen handles /when: the next mission reset, as stated by the source
+// when it says so, or the usual 00:10 UTC otherwise
+func formatWhen(cachedData CacheData, now time.Time) string {
+	reset, from := cachedData.NextReset, "as stated by the source"
+	if !reset.After(now) {
+		reset, from = nextReset(now), "the usual daily reset"
+	}
+
+	return fmt.Sprintf("Next mission reset: %s (in %s, %s)",
+		reset.UTC().Format("2006-01-02 15:04 MST"), formatAge(reset.Sub(now)), from)
+}
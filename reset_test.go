@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResetTime(t *testing.T) {
+	now := time.Date(2024, 3, 4, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		page   string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "countdown", page: "<p>Missions reset in 5h 23m</p>", want: now.Add(5*time.Hour + 23*time.Minute), wantOK: true},
+		{name: "countdown minutes only", page: "<p>Resets in: 45 minutes</p>", want: now.Add(45 * time.Minute), wantOK: true},
+		{name: "clock later today", page: "<span>Next reset: 23:00 UTC</span>", want: time.Date(2024, 3, 4, 23, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "clock tomorrow", page: "<span>Missions reset at 00:10 UTC</span>", want: time.Date(2024, 3, 5, 0, 10, 0, 0, time.UTC), wantOK: true},
+		{name: "clock out of range", page: "<span>Next reset: 25:00 UTC</span>"},
+		{name: "bad minutes", page: "<span>Next reset: 12:75 UTC</span>"},
+		{name: "countdown too far ahead", page: "<p>Missions reset in 30h</p>"},
+		{name: "countdown without numbers", page: "<p>Missions reset in a while</p>"},
+		{name: "not stated", page: "<p>80 140Ride the Lightning in Twine Peaks</p>"},
+	}
+	for _, tt := range tests {
+		got, ok := parseResetTime([]byte(tt.page), now)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("%s: parseResetTime = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
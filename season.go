@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// setSeason handles /season [YYYY-MM-DD|off]: the date /seasontotal sums from
+func setSeason(store *preferenceStore, chatID int64, args string, now time.Time) string {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	switch arg {
+	case "":
+		if start := store.get(chatID).SeasonStart; start != "" {
+			return fmt.Sprintf("Your season started on %s. See /seasontotal", start)
+		}
+		return "Usage: /season YYYY-MM-DD, e.g. /season 2024-06-01"
+	case "off":
+		if err := store.update(chatID, func(p *ChatPreferences) { p.SeasonStart = "" }); err != nil {
+			log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+			return "Sorry, your preference couldn't be saved. Please try again later."
+		}
+		return "Season cleared."
+	}
+
+	start, err := time.Parse(dateLayout, arg)
+	if err != nil {
+		return "Usage: /season YYYY-MM-DD, e.g. /season 2024-06-01"
+	}
+	if start.After(now.UTC()) {
+		return "The season start can't be in the future."
+	}
+
+	date := start.Format(dateLayout)
+	if err := store.update(chatID, func(p *ChatPreferences) { p.SeasonStart = date }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+	return fmt.Sprintf("Season start set to %s. /seasontotal sums the V-Bucks since then.", date)
+}
+
+// formatSeasonTotal handles /seasontotal: the V-Bucks the chat's filters let
+// through on every recorded day from its season start up to today
+// Days outside the history or never scraped make the total partial
+func formatSeasonTotal(h *historyStore, prefs ChatPreferences, now time.Time) string {
+	if prefs.SeasonStart == "" {
+		return "Set your season start first, e.g. /season 2024-06-01"
+	}
+
+	start, err := time.Parse(dateLayout, prefs.SeasonStart)
+	if err != nil {
+		return "Your season start isn't a valid date, set it again with /season YYYY-MM-DD"
+	}
+
+	today := now.UTC().Format(dateLayout)
+	var days, recorded, total, estimated int
+	for day := start; day.Format(dateLayout) <= today; day = day.AddDate(0, 0, 1) {
+		days++
+		missions, ok := h.missionsOn(day.Format(dateLayout))
+		if !ok {
+			continue
+		}
+		recorded++
+		dayTotal, dayEstimated := sumVBucks(filterForChat(prefs, missions))
+		total += dayTotal
+		estimated += dayEstimated
+	}
+
+	text := fmt.Sprintf("%d V-Bucks%s available since %s (%s).", total, estimateNote(estimated), prefs.SeasonStart, plural(days, "day"))
+	if recorded < days {
+		note := fmt.Sprintf("only %s recorded", plural(recorded, "day"))
+		if prefs.SeasonStart < h.oldestDate(now) {
+			note += fmt.Sprintf(", history only goes back %s", plural(h.retention, "day"))
+		}
+		text += fmt.Sprintf("\nThis is partial: %s.", note)
+	}
+	return text
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// unseenMissions returns the missions whose keys aren't in the seen-set
+func unseenMissions(seen map[string]string, vbucksMissions []VBucksMission) []VBucksMission {
+	var unseen []VBucksMission
+	for _, mission := range vbucksMissions {
+		if _, ok := seen[mission.key()]; !ok {
+			unseen = append(unseen, mission)
+		}
+	}
+	return unseen
+}
+
+// markSeen adds the missions to the chat's seen-set and drops entries that
+// fell out of the history retention window
+func markSeen(store *preferenceStore, chatID int64, vbucksMissions []VBucksMission, now time.Time) {
+	today := now.UTC().Format(dateLayout)
+	oldest := history.oldestDate(now)
+
+	err := store.update(chatID, func(p *ChatPreferences) {
+		if p.Seen == nil {
+			p.Seen = map[string]string{}
+		}
+		for key, date := range p.Seen {
+			if date < oldest {
+				delete(p.Seen, key)
+			}
+		}
+		for _, mission := range vbucksMissions {
+			p.Seen[mission.key()] = today
+		}
+	})
+	if err != nil {
+		log.Printf("Error saving seen missions for chat %d: %v", chatID, err)
+	}
+}
+
+// setOnlyNew handles /onlynew and returns the reply text
+func setOnlyNew(store *preferenceStore, chatID int64, args string) string {
+	var onlyNew bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		onlyNew = true
+	case "off":
+		onlyNew = false
+	default:
+		return "Usage: /onlynew on or /onlynew off"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.OnlyNew = onlyNew }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if onlyNew {
+		return "Daily notifications will now only include missions you haven't been sent before."
+	}
+	return "Daily notifications will now include every mission."
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback data sent by the /reset confirmation buttons
+const (
+	resetConfirmData = "reset:yes"
+	resetCancelData  = "reset:no"
+)
+
+// onOff renders a toggle for /settings
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// formatSettings lists the chat's preferences for /settings
+func formatSettings(prefs ChatPreferences) string {
+	area := "all areas"
+	if prefs.Area != "" {
+		area = prefs.Area
+	}
+
+	powerLevel := "any"
+	switch {
+	case prefs.MinPowerLevel > 0 && prefs.MaxPowerLevel > 0:
+		powerLevel = fmt.Sprintf("%d to %d", prefs.MinPowerLevel, prefs.MaxPowerLevel)
+	case prefs.MinPowerLevel > 0:
+		powerLevel = fmt.Sprintf("%d and up", prefs.MinPowerLevel)
+	case prefs.MaxPowerLevel > 0:
+		powerLevel = fmt.Sprintf("up to %d", prefs.MaxPowerLevel)
+	}
+
+	watched := "none"
+	if len(prefs.WatchedTypes) > 0 {
+		watched = strings.Join(prefs.WatchedTypes, ", ")
+	}
+
+	aliases := "none"
+	if len(prefs.Aliases) > 0 {
+		var pairs []string
+		for name, target := range prefs.Aliases {
+			pairs = append(pairs, "/"+name+" → /"+target)
+		}
+		sort.Strings(pairs)
+		aliases = strings.Join(pairs, ", ")
+	}
+
+	notifyMode := prefs.NotifyMode
+	if notifyMode == "" {
+		notifyMode = notifyFull
+	}
+
+	var result strings.Builder
+	result.WriteString("Your settings:\n")
+	result.WriteString(fmt.Sprintf("Daily notifications (/subscribe): %s\n", onOff(prefs.Subscribed)))
+	result.WriteString(fmt.Sprintf("Notification format (/notifymode): %s\n", notifyMode))
+	result.WriteString(fmt.Sprintf("Weekends only (/weekendsonly): %s\n", onOff(prefs.WeekendsOnly)))
+	result.WriteString(fmt.Sprintf("Only new missions (/onlynew): %s\n", onOff(prefs.OnlyNew)))
+	result.WriteString(fmt.Sprintf("Short note on repeat days (/shortrepeats): %s\n", onOff(prefs.ShortRepeats)))
+	result.WriteString(fmt.Sprintf("Keyword replies (/keywords): %s\n", onOff(prefs.Keywords)))
+	result.WriteString(fmt.Sprintf("Time zone (/timezone): %s\n", chatLocation(prefs)))
+	if prefs.RemindAt != "" {
+		result.WriteString(fmt.Sprintf("Daily reminder (/remindat): %s\n", prefs.RemindAt))
+	} else {
+		result.WriteString("Daily reminder (/remindat): off\n")
+	}
+	result.WriteString(fmt.Sprintf("Area (/onlyarea): %s\n", area))
+	result.WriteString(fmt.Sprintf("Power level (/plfilter): %s\n", powerLevel))
+	if prefs.MyPowerLevel > 0 {
+		result.WriteString(fmt.Sprintf("Your power level (/mypl): %d\n", prefs.MyPowerLevel))
+	} else {
+		result.WriteString("Your power level (/mypl): not set\n")
+	}
+	result.WriteString(fmt.Sprintf("Total line (/settotal): %s\n", onOff(!prefs.HideTotal)))
+	if prefs.Compact {
+		result.WriteString("Mission lines (/detail): compact\n")
+	} else {
+		result.WriteString("Mission lines (/detail): verbose\n")
+	}
+	result.WriteString(fmt.Sprintf("Watched mission types (/watchtype): %s\n", watched))
+	result.WriteString(fmt.Sprintf("Aliases (/alias): %s\n", aliases))
+	if prefs.Goal > 0 {
+		result.WriteString(fmt.Sprintf("V-Bucks goal (/goal): %d, %d earned\n", prefs.Goal, prefs.GoalEarned))
+	} else {
+		result.WriteString("V-Bucks goal (/goal): none\n")
+	}
+	if prefs.SeasonStart != "" {
+		result.WriteString(fmt.Sprintf("Season start (/season): %s\n", prefs.SeasonStart))
+	} else {
+		result.WriteString("Season start (/season): none\n")
+	}
+	if prefs.WorthThreshold > 0 {
+		result.WriteString(fmt.Sprintf("Worth playing bar (/worth): %d V-Bucks\n", prefs.WorthThreshold))
+	} else {
+		result.WriteString("Worth playing bar (/worth): your average\n")
+	}
+	result.WriteString("\nUse /reset to restore the defaults.")
+
+	return result.String()
+}
+
+// askReset handles /reset by asking for confirmation with yes/no buttons
+func askReset(bot *tgbotapi.BotAPI, chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "Reset all your settings to the defaults? This also unsubscribes you from daily notifications.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Yes, reset", resetConfirmData),
+		tgbotapi.NewInlineKeyboardButtonData("No", resetCancelData),
+	))
+	send(bot, msg)
+}
+
+// editMessage edits a message in place, reporting whether the message already
+// read that way; an edit to the same content is a no-op, not an error
+func editMessage(bot *tgbotapi.BotAPI, edit tgbotapi.EditMessageTextConfig) (unchanged bool) {
+	if _, err := bot.Request(edit); err != nil {
+		if isNotModified(err) {
+			return true
+		}
+		log.Printf("Error editing message in chat %d: %v", edit.ChatID, err)
+	}
+	return false
+}
+
+// handleCallback answers presses of the bot's inline keyboard buttons
+func handleCallback(t *tenant, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+
+	// Missions marked done from /progress update the message in place
+	if strings.HasPrefix(query.Data, goalDonePrefix) {
+		text, keyboard := markGoalMissionDone(t, chatID, strings.TrimPrefix(query.Data, goalDonePrefix))
+		edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		edit.ReplyMarkup = keyboard
+		answer := "Marked as done"
+		if editMessage(t.bot, edit) {
+			answer = "Already up to date"
+		}
+		if _, err := t.bot.Request(tgbotapi.NewCallback(query.ID, answer)); err != nil {
+			log.Printf("Error answering callback from chat %d: %v", chatID, err)
+		}
+		return
+	}
+
+	var text string
+	switch query.Data {
+	case resetConfirmData:
+		if err := t.prefs.reset(chatID); err != nil {
+			log.Printf("Error resetting preferences for chat %d: %v", chatID, err)
+			text = "Sorry, your settings couldn't be reset. Please try again later."
+		} else {
+			text = "Your settings are back to the defaults."
+		}
+	case resetCancelData:
+		text = "Reset cancelled, your settings are unchanged."
+	default:
+		log.Printf("Unknown callback data %q from chat %d", query.Data, chatID)
+		return
+	}
+
+	// Replace the question so the buttons can't be pressed again
+	editMessage(t.bot, tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text))
+	if _, err := t.bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("Error answering callback from chat %d: %v", chatID, err)
+	}
+}
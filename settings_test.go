@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSetShowTotal(t *testing.T) {
+	store := newTestStore(t)
+	missions := []VBucksMission{{Amount: "80", PowerLevel: "140", MissionType: "Ride the Lightning", Area: "Twine Peaks"}}
+
+	tests := []struct {
+		args      string
+		wantTotal bool
+	}{
+		{args: "off", wantTotal: false},
+		{args: "on", wantTotal: true},
+	}
+	for _, tt := range tests {
+		setShowTotal(store, 1, tt.args)
+		text := formatMissionsForChat(store.get(1), formatOptions{}, missions)
+		if got := strings.Contains(text, "Total:"); got != tt.wantTotal {
+			t.Errorf("/settotal %s: total line shown = %v, want %v:\n%s", tt.args, got, tt.wantTotal, text)
+		}
+	}
+
+	// Chats that never chose keep the total
+	if text := formatMissionsForChat(store.get(2), formatOptions{}, missions); !strings.Contains(text, "Total:") {
+		t.Errorf("total line hidden by default:\n%s", text)
+	}
+	if reply := setShowTotal(store, 1, "maybe"); !strings.HasPrefix(reply, "Usage:") {
+		t.Errorf("/settotal maybe = %q, want the usage", reply)
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          int
+		desc          string
+		wantUnchanged bool
+	}{
+		{name: "edited"},
+		{name: "not modified", code: http.StatusBadRequest, desc: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same", wantUnchanged: true},
+		{name: "message gone", code: http.StatusBadRequest, desc: "Bad Request: message to edit not found"},
+		{name: "server error", code: http.StatusInternalServerError, desc: "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		fake, bot := newFakeTelegram(t)
+		fake.setFail(func(telegramRequest) (int, string) { return tt.code, tt.desc })
+		if got := editMessage(bot, tgbotapi.NewEditMessageText(1, 10, "text")); got != tt.wantUnchanged {
+			t.Errorf("%s: editMessage = %v, want %v", tt.name, got, tt.wantUnchanged)
+		}
+	}
+}
+
+// callbackAnswers returns the texts the bot answered button presses with
+func callbackAnswers(fake *fakeTelegram) []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	var answers []string
+	for _, req := range fake.requests {
+		if req.Method == "answerCallbackQuery" {
+			answers = append(answers, req.Form.Get("text"))
+		}
+	}
+	return answers
+}
+
+func TestGoalButtonPressedTwice(t *testing.T) {
+	inTempDir(t)
+	withFixture(t, testMissions)
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	if err := tn.prefs.update(1, func(p *ChatPreferences) { p.Goal = 1000 }); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second press renders the same message, which Telegram refuses
+	edits := 0
+	fake.setFail(func(req telegramRequest) (int, string) {
+		if req.Method != "editMessageText" {
+			return 0, ""
+		}
+		if edits++; edits > 1 {
+			return http.StatusBadRequest, "Bad Request: message is not modified"
+		}
+		return 0, ""
+	})
+
+	id := goalMissionID(time.Now().UTC().Format(dateLayout), testMissions[0])
+	query := &tgbotapi.CallbackQuery{
+		ID:      "q",
+		Data:    goalDonePrefix + id,
+		Message: &tgbotapi.Message{MessageID: 10, Chat: &tgbotapi.Chat{ID: 1}},
+	}
+	handleCallback(tn, query)
+	handleCallback(tn, query)
+
+	want := []string{"Marked as done", "Already up to date"}
+	if got := callbackAnswers(fake); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("button answers = %q, want %q", got, want)
+	}
+	if earned := tn.prefs.get(1).GoalEarned; earned != 80 {
+		t.Errorf("GoalEarned = %d after pressing the button twice, want 80", earned)
+	}
+}
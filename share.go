@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sharePayloadPattern matches the /start payload of a shared day's link,
+// e.g. "day_20241031"; Telegram allows up to 64 of A-Z, a-z, 0-9, _ and -
+var sharePayloadPattern = regexp.MustCompile(`^day_(\d{8})$`)
+
+// sharePayloadLayout is how dates are written in share payloads, since
+// Telegram doesn't allow every character of dateLayout's separators
+const sharePayloadLayout = "20060102"
+
+// shareLink handles /share: a t.me link that opens the bot on the given day's
+// missions, today's by default
+func shareLink(botName, args string) string {
+	day := time.Now().UTC().Format(dateLayout)
+	if arg := strings.TrimSpace(args); arg != "" && arg != day {
+		date, err := history.parseHistoryDate(arg)
+		if err != nil {
+			return "Can't share that day: " + err.Error() + "."
+		}
+		if _, ok := history.missionsOn(date); !ok {
+			return fmt.Sprintf("No missions were recorded on %s.", date)
+		}
+		day = date
+	}
+
+	parsed, _ := time.Parse(dateLayout, day)
+	return fmt.Sprintf("Share the V-Bucks missions of %s with this link:\nhttps://t.me/%s?start=day_%s",
+		day, botName, parsed.Format(sharePayloadLayout))
+}
+
+// parseSharePayload returns the date a /start payload points at, if it's a
+// share link's payload
+func parseSharePayload(payload string) (string, bool) {
+	match := sharePayloadPattern.FindStringSubmatch(strings.TrimSpace(payload))
+	if match == nil {
+		return "", false
+	}
+	day, err := time.Parse(sharePayloadLayout, match[1])
+	if err != nil {
+		return "", false
+	}
+	return day.Format(dateLayout), true
+}
@@ -0,0 +1,795 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/gocolly/colly/v2"
+)
+
+// SourceKind selects how a Source is fetched and parsed
+type SourceKind string
+
+const (
+	// SourceKindHTML scrapes a page with colly
+	SourceKindHTML SourceKind = "html"
+	// SourceKindJSON fetches a JSON payload and maps it directly onto missions
+	SourceKindJSON SourceKind = "json"
+)
+
+// Source describes a place V-Bucks missions are fetched from
+type Source struct {
+	Name string
+	Kind SourceKind
+	URL  string
+
+	// Path is the dot-separated key path to the missions array inside a JSON
+	// payload. Leave it empty when the payload itself is the array.
+	Path string
+
+	// Fields maps VBucksMission field names (Area, PowerLevel, Amount,
+	// MissionType, Modifiers) to the keys used by a JSON payload. Unmapped
+	// fields are looked up under their own name.
+	Fields map[string]string
+
+	// Selectors configure a generic HTML source. Leave it unset to use the
+	// bespoke freethevbucks parser.
+	Selectors *Selectors
+	// Priority decides whose entries are kept when sources disagree about a
+	// mission; higher wins, and sources listed first win ties
+	Priority int
+	// MinInterval is the least time between two fetches of the source, such
+	// as "30m"; scrapes in between reuse its last missions. Empty fetches it
+	// on every scrape
+	MinInterval string `json:",omitempty"`
+}
+
+// Selectors are the CSS selectors a generic HTML source is parsed with
+type Selectors struct {
+	// Mission matches one element per mission; the field selectors are
+	// matched within it
+	Mission     string
+	Area        string
+	Amount      string
+	PowerLevel  string
+	MissionType string
+	// Modifiers matches one element per modifier, if the source lists them
+	Modifiers string
+	// Date matches the day the mission is for, if the source lists missions
+	// ahead of time
+	Date string
+	// Container matches the element the missions are listed in, if the page
+	// has one; a page without it parsing to no missions is treated as a
+	// layout change rather than a day without missions
+	Container string
+}
+
+// validate checks that the selectors needed to build a mission are present
+// and compile
+func (s *Selectors) validate() error {
+	fields := []struct {
+		name     string
+		selector string
+		required bool
+	}{
+		{"Mission", s.Mission, true},
+		{"Area", s.Area, true},
+		{"Amount", s.Amount, true},
+		{"PowerLevel", s.PowerLevel, false},
+		{"MissionType", s.MissionType, false},
+		{"Modifiers", s.Modifiers, false},
+		{"Date", s.Date, false},
+		{"Container", s.Container, false},
+	}
+	for _, f := range fields {
+		if f.selector == "" {
+			if f.required {
+				return fmt.Errorf("missing %s selector", f.name)
+			}
+			continue
+		}
+		if _, err := cascadia.Compile(f.selector); err != nil {
+			return fmt.Errorf("invalid %s selector %q: %v", f.name, f.selector, err)
+		}
+	}
+	return nil
+}
+
+// defaultSource is the site the bot has always scraped
+var defaultSource = Source{
+	Name: "freethevbucks",
+	Kind: SourceKindHTML,
+	URL:  "https://freethevbucks.com/timed-missions/",
+}
+
+// sources holds the sources missions are fetched from, set at startup
+var sources = []Source{defaultSource}
+
+// configuredSources returns the sources in use, for readers outside of scraping
+func configuredSources() []Source {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return sources
+}
+
+// loadScrapeURL reads SCRAPE_URL, the page the default source scrapes, e.g. a
+// staging mirror or an archived snapshot; unset means the usual site
+func loadScrapeURL() (string, error) {
+	v, ok := os.LookupEnv("SCRAPE_URL")
+	if !ok {
+		return defaultSource.URL, nil
+	}
+	u, err := url.Parse(strings.TrimSpace(v))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid SCRAPE_URL %q: must be an http or https URL", v)
+	}
+	return u.String(), nil
+}
+
+// loadSources reads the source list from sourcesFile, falling back to the
+// default source, at SCRAPE_URL, when the file doesn't exist
+func loadSources() ([]Source, error) {
+	if _, err := os.Stat(sourcesFile); os.IsNotExist(err) {
+		// SCRAPE_URL only applies to the default source, so it's only read here
+		scrapeURL, err := loadScrapeURL()
+		if err != nil {
+			return nil, err
+		}
+		src := defaultSource
+		src.URL = scrapeURL
+		return []Source{src}, nil
+	}
+
+	data, err := ioutil.ReadFile(sourcesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", sourcesFile, err)
+	}
+
+	var loaded []Source
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", sourcesFile, err)
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("%s doesn't define any sources", sourcesFile)
+	}
+
+	for i, src := range loaded {
+		if src.Name == "" {
+			return nil, fmt.Errorf("source %d in %s has no name", i+1, sourcesFile)
+		}
+		if src.URL == "" {
+			return nil, fmt.Errorf("source %q has no URL", src.Name)
+		}
+		switch src.Kind {
+		case SourceKindHTML, SourceKindJSON:
+		default:
+			return nil, fmt.Errorf("source %q has unknown kind %q", src.Name, src.Kind)
+		}
+		if src.MinInterval != "" {
+			if d, err := time.ParseDuration(src.MinInterval); err != nil || d < 0 {
+				return nil, fmt.Errorf("source %q has invalid MinInterval %q: must be a duration like 30m", src.Name, src.MinInterval)
+			}
+		}
+		if src.Selectors != nil {
+			if src.Kind != SourceKindHTML {
+				return nil, fmt.Errorf("source %q: selectors only apply to html sources", src.Name)
+			}
+			if err := src.Selectors.validate(); err != nil {
+				return nil, fmt.Errorf("source %q: %v", src.Name, err)
+			}
+		}
+	}
+
+	return loaded, nil
+}
+
+// fetch retrieves the missions offered by the source
+func (s Source) fetch() ([]VBucksMission, error) {
+	switch s.Kind {
+	case SourceKindJSON:
+		return fetchJSONMissions(s)
+	default:
+		return fetchHTMLMissions(s)
+	}
+}
+
+const (
+	// maxConcurrentSources is how many sources are scraped at the same time
+	maxConcurrentSources = 4
+	// defaultSourceTimeout bounds how long one source may take, long enough
+	// for the headless fallback to finish
+	defaultSourceTimeout = 90 * time.Second
+)
+
+// sourceTimeout is set with SOURCE_TIMEOUT
+var sourceTimeout = defaultSourceTimeout
+
+// sourceResult is what fetching one source gave
+type sourceResult struct {
+	missions []VBucksMission
+	err      error
+	took     time.Duration
+}
+
+// fetchWithTimeout fetches a source, giving up on it after timeout
+// A source that times out keeps running in the background, but its result is
+// dropped so it can't hold up the others
+func fetchWithTimeout(src Source, timeout time.Duration) sourceResult {
+	start := time.Now()
+	done := make(chan sourceResult, 1)
+	go func() {
+		missions, err := src.fetch()
+		done <- sourceResult{missions: missions, err: err, took: time.Since(start)}
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(timeout):
+		return sourceResult{err: fmt.Errorf("timed out after %s", timeout), took: timeout}
+	}
+}
+
+// fetchSources fetches every source concurrently, at most
+// maxConcurrentSources at a time, returning the results in the sources' order
+func fetchSources(srcs []Source, timeout time.Duration) []sourceResult {
+	results := make([]sourceResult, len(srcs))
+	slots := make(chan struct{}, maxConcurrentSources)
+
+	var wg sync.WaitGroup
+	for i, src := range srcs {
+		wg.Add(1)
+		go func(i int, src Source) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			results[i] = fetchWithTimeout(src, timeout)
+		}(i, src)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// fetchMissions fetches V-Bucks missions from every configured source,
+// returning them merged and by source name
+// Sources fetched less than their MinInterval ago give their last missions
+// instead of being fetched again
+// Sources that fail are skipped; an error is only returned if all of them fail
+func fetchMissions() ([]VBucksMission, map[string][]VBucksMission, error) {
+	bySource := map[string][]VBucksMission{}
+	var errs []string
+
+	start := time.Now()
+	var due []Source
+	for _, src := range sources {
+		if poll, ok := recentPoll(src, start); ok {
+			log.Printf("%s was fetched %s ago, reusing its missions", src.Name, formatAge(start.Sub(poll.at)))
+			bySource[src.Name] = poll.missions
+			continue
+		}
+		due = append(due, src)
+	}
+
+	for i, result := range fetchSources(due, sourceTimeout) {
+		src := due[i]
+		fetchStatus.RecordSource(src.Name, len(result.missions), result.took, result.err)
+
+		if result.err != nil {
+			log.Printf("Error fetching missions from %s: %v", src.Name, result.err)
+			errs = append(errs, fmt.Sprintf("%s: %v", src.Name, result.err))
+			continue
+		}
+		normalizeAmounts(src.Name, result.missions)
+		recordPoll(src.Name, result.missions, start)
+		bySource[src.Name] = result.missions
+	}
+	vbucksMissions := mergeSources(sources, bySource)
+
+	var err error
+	if len(errs) == len(sources) {
+		err = fmt.Errorf("all sources failed: %s", strings.Join(errs, "; "))
+	}
+	latency := time.Since(start)
+	fetchStatus.RecordFetch(len(vbucksMissions), latency, err)
+
+	statsd.count(metricScrapes, 1)
+	statsd.timing(metricScrapeDuration, latency)
+	if err != nil {
+		statsd.count(metricScrapeFailures, 1)
+	} else {
+		statsd.gauge(metricMissions, len(vbucksMissions))
+	}
+	callback.notify(vbucksMissions, err)
+
+	return vbucksMissions, bySource, err
+}
+
+// normalizeAmounts rewrites each mission's reward as a plain number, so
+// "1,000" and "1000" are cached the same way; rewards that can't be read are
+// logged and left as scraped
+func normalizeAmounts(source string, missions []VBucksMission) {
+	for i, mission := range missions {
+		amount, err := parseAmount(mission.Amount)
+		if err != nil {
+			log.Printf("Error parsing %s mission in %s from %s: %v", mission.MissionType, mission.Area, source, err)
+			continue
+		}
+		missions[i].Amount = strconv.Itoa(amount)
+	}
+}
+
+const (
+	// defaultScrapeDelay is the least time between two requests to a site
+	defaultScrapeDelay = 2 * time.Second
+	// defaultScrapeJitter is the most random time added to the delay
+	defaultScrapeJitter = time.Second
+)
+
+// baseCollector carries the request limits; HTML sources are scraped with
+// clones of it, which share its limits so they apply across scrapes
+var baseCollector = colly.NewCollector()
+
+// loadScrapeLimits reads SCRAPE_DELAY, SCRAPE_JITTER and SOURCE_TIMEOUT and
+// sets up the collector HTML sources are scraped with
+func loadScrapeLimits() error {
+	delay, err := durationSetting("SCRAPE_DELAY", defaultScrapeDelay)
+	if err != nil {
+		return err
+	}
+	jitter, err := durationSetting("SCRAPE_JITTER", defaultScrapeJitter)
+	if err != nil {
+		return err
+	}
+	timeout, err := durationSetting("SOURCE_TIMEOUT", defaultSourceTimeout)
+	if err != nil {
+		return err
+	}
+	if timeout == 0 {
+		return fmt.Errorf("invalid SOURCE_TIMEOUT %q: must be longer than zero", os.Getenv("SOURCE_TIMEOUT"))
+	}
+	sourceTimeout = timeout
+
+	c := colly.NewCollector()
+	if err := c.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: 1, Delay: delay, RandomDelay: jitter}); err != nil {
+		return fmt.Errorf("failed to set scrape limits: %v", err)
+	}
+	baseCollector = c
+
+	log.Printf("Scraping at most one request per site every %s plus up to %s of jitter", delay, jitter)
+	return nil
+}
+
+// durationSetting reads a duration such as "1500ms" or "2s" from the
+// environment, falling back to def when it isn't set
+func durationSetting(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a duration like 2s", name, v)
+	}
+	return d, nil
+}
+
+// missionSelector matches the notices holding V-Bucks missions
+const missionSelector = "div.news-link div.infonotice"
+
+// missionContainerSelector matches the block the notices are listed in,
+// which is there even on days without missions
+const missionContainerSelector = "div.news-link"
+
+// fetchHTMLMissions scrapes the source page for V-Bucks missions
+func fetchHTMLMissions(src Source) ([]VBucksMission, error) {
+	// Clone the shared collector so its request limits apply
+	c := baseCollector.Clone()
+
+	// Keep the response so it can be checked before parsing
+	var status int
+	var header http.Header
+	var body []byte
+
+	// Remember what the source answered with for /stats
+	c.OnResponse(func(r *colly.Response) {
+		fetchStatus.RecordHTTPStatus(src.Name, r.StatusCode)
+		status, body = r.StatusCode, r.Body
+		if r.Headers != nil {
+			header = *r.Headers
+		}
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		fetchStatus.RecordHTTPStatus(src.Name, r.StatusCode)
+		status, body = r.StatusCode, r.Body
+		if r.Headers != nil {
+			header = *r.Headers
+		}
+	})
+
+	// Start the scraping process
+	visitErr := c.Visit(src.URL)
+
+	// Render the page in a browser if we got an anti-bot challenge instead
+	if isChallenge(status, header, body) {
+		if !headlessFallback {
+			return nil, fmt.Errorf("blocked by an anti-bot challenge (HTTP %d); set HEADLESS_FALLBACK=1 to render the page in a headless browser", status)
+		}
+
+		log.Printf("%s answered with a challenge page, rendering it headless", src.Name)
+		rendered, err := renderHeadless(src.URL, src.missionSelector())
+		if err != nil {
+			return nil, fmt.Errorf("headless fallback failed: %v", err)
+		}
+		body, status, visitErr = rendered, http.StatusOK, nil
+	}
+
+	// Error and maintenance pages would parse to nothing or to junk
+	if err := checkSourcePage(status, body); err != nil {
+		saveRawHTML(src, body)
+		return nil, err
+	}
+	if visitErr != nil {
+		return nil, visitErr
+	}
+
+	// Keep the page around for offline parser debugging
+	saveRawHTML(src, body)
+
+	// The parsers expect UTF-8 whatever the page was served in
+	body = toUTF8(src.Name, body, header.Get("Content-Type"))
+
+	// Prefer the page's own idea of when the missions rotate
+	reset, _ := parseResetTime(body, time.Now())
+	fetchStatus.RecordReset(src.Name, reset)
+
+	return parseSourceHTML(src, body)
+}
+
+// missionSelector returns the selector matching the source's mission elements
+func (s Source) missionSelector() string {
+	if s.Selectors != nil {
+		return s.Selectors.Mission
+	}
+	return missionSelector
+}
+
+// parseSourceHTML parses a page with the source's selectors, or with the
+// bespoke parser when it has none
+func parseSourceHTML(src Source, body []byte) ([]VBucksMission, error) {
+	if src.Selectors == nil {
+		return parseMissionsHTML(body)
+	}
+	return parseSelectorHTML(*src.Selectors, body)
+}
+
+// parseSelectorHTML extracts missions from a page using configured selectors
+func parseSelectorHTML(sel Selectors, body []byte) ([]VBucksMission, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	// The page was fetched already, the element only needs a response to hang off
+	resp := &colly.Response{Request: &colly.Request{}}
+
+	var vbucksMissions []VBucksMission
+	doc.Find(sel.Mission).Each(func(i int, s *goquery.Selection) {
+		e := colly.NewHTMLElementFromSelectionNode(resp, s, s.Nodes[0], i)
+
+		mission := VBucksMission{
+			Area:        normalizeArea(e.ChildText(sel.Area)),
+			Amount:      e.ChildText(sel.Amount),
+			PowerLevel:  childText(e, sel.PowerLevel),
+			MissionType: childText(e, sel.MissionType),
+		}
+		if sel.Modifiers != "" {
+			mission.Modifiers = cleanModifiers(e.ChildTexts(sel.Modifiers))
+		}
+		if sel.Date != "" {
+			mission.Date = normalizeMissionDate(e.ChildText(sel.Date), time.Now())
+		}
+		// Elements without the essentials aren't missions
+		if mission.Area == "" || mission.Amount == "" {
+			return
+		}
+		mission.Suspect = mission.suspectReason() != ""
+
+		vbucksMissions = append(vbucksMissions, mission)
+	})
+
+	if len(vbucksMissions) == 0 && sel.Container != "" {
+		if err := checkContainer(doc, sel.Container); err != nil {
+			return nil, err
+		}
+	}
+	return vbucksMissions, nil
+}
+
+// layoutChangedError is returned for a page with no missions and no missions
+// container, which /health reports as suspected breakage
+type layoutChangedError struct {
+	container string
+}
+
+func (e *layoutChangedError) Error() string {
+	return fmt.Sprintf("no missions and no %q element on the page, its layout may have changed", e.container)
+}
+
+// isLayoutChanged reports whether a fetch failed because the page's missions
+// container is gone
+func isLayoutChanged(err error) bool {
+	var layoutErr *layoutChangedError
+	return errors.As(err, &layoutErr)
+}
+
+// checkContainer tells a day without missions, where the page still has its
+// missions container, from a page whose layout changed under the parser
+func checkContainer(doc *goquery.Document, container string) error {
+	if doc.Find(container).Length() == 0 {
+		return &layoutChangedError{container: container}
+	}
+	return nil
+}
+
+// childText is ChildText for optional selectors, empty when unset
+func childText(e *colly.HTMLElement, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	return e.ChildText(selector)
+}
+
+// parseMissionsHTML extracts the missions from the freethevbucks page's HTML
+func parseMissionsHTML(body []byte) ([]VBucksMission, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	// Create a slice to store V-Bucks missions
+	var vbucksMissions []VBucksMission
+
+	// Look for divs containing V-Bucks missions
+	doc.Find(missionSelector).Each(func(_ int, e *goquery.Selection) {
+		text := e.Text()
+
+		// Skip the support-a-creator div
+		if isSupportNotice(text) {
+			return
+		}
+
+		if mission, ok := parseMissionText(text); ok {
+			mission.Modifiers = noticeModifiers(e)
+			vbucksMissions = append(vbucksMissions, mission)
+		}
+	})
+
+	if len(vbucksMissions) == 0 {
+		if err := checkContainer(doc, missionContainerSelector); err != nil {
+			return nil, err
+		}
+	}
+	return vbucksMissions, nil
+}
+
+// noticeModifiers reads the modifier icons of a notice from their alt or
+// title text; notices without any give nil
+func noticeModifiers(e *goquery.Selection) []string {
+	var names []string
+	e.Find("img").Each(func(_ int, img *goquery.Selection) {
+		name, ok := img.Attr("alt")
+		if !ok || strings.TrimSpace(name) == "" {
+			name, _ = img.Attr("title")
+		}
+		names = append(names, name)
+	})
+	return cleanModifiers(names)
+}
+
+// cleanModifiers trims modifier names and drops empty ones
+func cleanModifiers(names []string) []string {
+	var modifiers []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			modifiers = append(modifiers, name)
+		}
+	}
+	return modifiers
+}
+
+// supportNoticePattern matches the wording of a support-a-creator notice
+// regardless of which creator code it advertises
+var supportNoticePattern = regexp.MustCompile(`(?i)\buse\s+(creator\s+)?code\b|support[\s-]+a[\s-]+creator`)
+
+// isSupportNotice reports whether a notice is a support-a-creator plug rather
+// than a mission
+func isSupportNotice(text string) bool {
+	return supportNoticePattern.MatchString(text)
+}
+
+// parseMissionText parses a notice like "40 124Ride the Lightning in Twine Peaks"
+// Returns false when the text doesn't look like a mission
+func parseMissionText(text string) (VBucksMission, bool) {
+	text = strings.TrimSpace(text)
+
+	// Split by "in" to get the area
+	parts := strings.Split(text, " in ")
+	if len(parts) < 2 {
+		return VBucksMission{}, false
+	}
+
+	area := resolveArea(parts[1])
+	mainPart := parts[0]
+
+	// Split the main part by spaces
+	fields := strings.Fields(mainPart)
+	if len(fields) < 2 {
+		return VBucksMission{}, false
+	}
+
+	// First field is amount, second is power level, rest is mission type
+	amount := fields[0]
+	powerLevel := fields[1]
+
+	// Check if power level has other text attached
+	powerLevelDigits := ""
+	missionType := ""
+
+	for i, c := range powerLevel {
+		if c >= '0' && c <= '9' {
+			powerLevelDigits += string(c)
+		} else if c == '-' && powerLevelDigits != "" && i+1 < len(powerLevel) && powerLevel[i+1] >= '0' && powerLevel[i+1] <= '9' {
+			// A dash between digits is a power level range like "76-82"
+			powerLevelDigits += string(c)
+		} else {
+			// Once we hit non-digits, the rest is part of the mission type
+			missionType = powerLevel[i:] + " " + strings.Join(fields[2:], " ")
+			break
+		}
+	}
+
+	// If we didn't find any non-digits, then the mission type is just the remaining fields
+	if missionType == "" {
+		missionType = strings.Join(fields[2:], " ")
+	}
+
+	mission := VBucksMission{
+		Amount:      amount,
+		PowerLevel:  powerLevelDigits,
+		MissionType: strings.TrimSpace(missionType),
+		Area:        normalizeArea(area),
+	}
+	mission.Suspect = mission.suspectReason() != ""
+
+	return mission, true
+}
+
+// jsonClient is used for JSON sources, which bypass colly entirely
+var jsonClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchJSONMissions downloads a JSON payload and maps it onto missions
+func fetchJSONMissions(src Source) ([]VBucksMission, error) {
+	resp, err := jsonClient.Get(src.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	fetchStatus.RecordHTTPStatus(src.Name, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJSONMissions(data, src)
+}
+
+// parseJSONMissions unmarshals a JSON payload into missions using the
+// source's path and field mapping
+func parseJSONMissions(data []byte, src Source) ([]VBucksMission, error) {
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	// Walk down to the missions array
+	if src.Path != "" {
+		for _, key := range strings.Split(src.Path, ".") {
+			obj, ok := payload.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("path %q: %q is not inside an object", src.Path, key)
+			}
+			payload = obj[key]
+		}
+	}
+
+	records, ok := payload.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of missions at path %q", src.Path)
+	}
+
+	var vbucksMissions []VBucksMission
+	for i, record := range records {
+		obj, ok := record.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("mission %d is not an object", i)
+		}
+
+		mission := VBucksMission{
+			Area:        normalizeArea(jsonField(obj, src.Fields, "Area")),
+			PowerLevel:  jsonField(obj, src.Fields, "PowerLevel"),
+			Amount:      jsonField(obj, src.Fields, "Amount"),
+			MissionType: jsonField(obj, src.Fields, "MissionType"),
+			Modifiers:   jsonStrings(obj, src.Fields, "Modifiers"),
+			Date:        normalizeMissionDate(jsonField(obj, src.Fields, "Date"), time.Now()),
+		}
+		mission.Suspect = mission.suspectReason() != ""
+
+		vbucksMissions = append(vbucksMissions, mission)
+	}
+
+	return vbucksMissions, nil
+}
+
+// jsonField looks up a mission field in a JSON object and returns it as a string
+func jsonField(obj map[string]interface{}, fields map[string]string, name string) string {
+	key := name
+	if mapped, ok := fields[name]; ok && mapped != "" {
+		key = mapped
+	}
+
+	switch v := obj[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonStrings looks up a mission field holding a list of strings, such as the
+// modifiers; anything but an array gives nil
+func jsonStrings(obj map[string]interface{}, fields map[string]string, name string) []string {
+	key := name
+	if mapped, ok := fields[name]; ok && mapped != "" {
+		key = mapped
+	}
+
+	values, ok := obj[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			names = append(names, s)
+		}
+	}
+	return cleanModifiers(names)
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSourcesScrapeURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		scrapeURL string
+		want      string
+		wantErr   bool
+	}{
+		{name: "mirror", scrapeURL: "http://localhost:8080/snapshot.html", want: "http://localhost:8080/snapshot.html"},
+		{name: "trimmed", scrapeURL: " https://mirror.example/timed-missions/ ", want: "https://mirror.example/timed-missions/"},
+		{name: "empty", scrapeURL: "", wantErr: true},
+		{name: "no scheme", scrapeURL: "freethevbucks.com", wantErr: true},
+		{name: "not http", scrapeURL: "ftp://mirror.example/missions", wantErr: true},
+		{name: "no host", scrapeURL: "https://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			t.Setenv("SCRAPE_URL", tt.scrapeURL)
+
+			loaded, err := loadSources()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("loadSources accepted SCRAPE_URL %q", tt.scrapeURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded) != 1 || loaded[0].URL != tt.want {
+				t.Errorf("loadSources = %+v, want the default source at %s", loaded, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSourcesIgnoresScrapeURLWithSourcesFile(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("SCRAPE_URL", "not a url")
+	data := `[{"Name": "mirror", "Kind": "html", "URL": "https://mirror.example/"}]`
+	if err := ioutil.WriteFile(sourcesFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadSources()
+	if err != nil {
+		t.Fatalf("loadSources failed on SCRAPE_URL, which sources.json overrides: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Name != "mirror" {
+		t.Errorf("loadSources = %+v, want the source from %s", loaded, sourcesFile)
+	}
+}
+
+func TestNormalizeAmounts(t *testing.T) {
+	missions := []VBucksMission{
+		{Amount: "1,000", MissionType: "Fight the Storm", Area: "Canny Valley"},
+		{Amount: "80 V-Bucks", MissionType: "Ride the Lightning", Area: "Twine Peaks"},
+		{Amount: "lots", MissionType: "Retrieve the Data", Area: "Plankerton"},
+	}
+	normalizeAmounts("test", missions)
+
+	for i, want := range []string{"1000", "80", "lots"} {
+		if missions[i].Amount != want {
+			t.Errorf("mission %d amount = %q, want %q", i+1, missions[i].Amount, want)
+		}
+	}
+}
+
+func TestParseJSONMissions(t *testing.T) {
+	payload := `{"data": {"missions": [
+		{"zone": "Twine Peaks", "pl": 140, "reward": 80, "type": "Ride the Lightning"},
+		{"zone": " canny valley ", "pl": "76-82", "reward": "50", "type": "Fight the Storm", "Modifiers": ["Fire Storm", " "]}
+	]}}`
+	src := Source{
+		Name:   "api",
+		Kind:   SourceKindJSON,
+		Path:   "data.missions",
+		Fields: map[string]string{"Area": "zone", "PowerLevel": "pl", "Amount": "reward", "MissionType": "type"},
+	}
+
+	got, err := parseJSONMissions([]byte(payload), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VBucksMission{
+		{Area: "Twine Peaks", PowerLevel: "140", Amount: "80", MissionType: "Ride the Lightning"},
+		{Area: "Canny Valley", PowerLevel: "76-82", Amount: "50", MissionType: "Fight the Storm", Modifiers: []string{"Fire Storm"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONMissions =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseJSONMissionsErrors(t *testing.T) {
+	tests := map[string]string{
+		"invalid JSON":       `{"missions": [`,
+		"path not found":     `{"other": []}`,
+		"not an array":       `{"missions": {"Area": "Twine Peaks"}}`,
+		"mission not object": `{"missions": ["Twine Peaks"]}`,
+	}
+	src := Source{Name: "api", Kind: SourceKindJSON, Path: "missions"}
+	for name, payload := range tests {
+		if _, err := parseJSONMissions([]byte(payload), src); err == nil {
+			t.Errorf("%s: parseJSONMissions accepted %s", name, payload)
+		}
+	}
+}
+
+func TestParseMissionsHTML(t *testing.T) {
+	body, err := ioutil.ReadFile("testdata/timed-missions.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseMissionsHTML(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VBucksMission{
+		{Area: "Twine Peaks", PowerLevel: "140", Amount: "80", MissionType: "Ride the Lightning"},
+		{Area: "Canny Valley", PowerLevel: "76-82", Amount: "1,000", MissionType: "Fight the Storm", Modifiers: []string{"Fire Storm"}},
+		{Area: "Plankerton", PowerLevel: "64", Amount: "50", MissionType: "Retrieve the Data", Modifiers: []string{"Smashers"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMissionsHTML =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseMissionText(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   VBucksMission
+		wantOK bool
+	}{
+		{
+			text:   "40 124Ride the Lightning in Twine Peaks",
+			want:   VBucksMission{Area: "Twine Peaks", PowerLevel: "124", Amount: "40", MissionType: "Ride the Lightning"},
+			wantOK: true,
+		},
+		{
+			text:   "  50 76-82 Fight the Storm in Canny Valley ",
+			want:   VBucksMission{Area: "Canny Valley", PowerLevel: "76-82", Amount: "50", MissionType: "Fight the Storm"},
+			wantOK: true,
+		},
+		{text: "Missions refresh daily", wantOK: false},
+		{text: "40 in Twine Peaks", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMissionText(tt.text)
+		if ok != tt.wantOK {
+			t.Errorf("parseMissionText(%q) ok = %v, want %v", tt.text, ok, tt.wantOK)
+			continue
+		}
+		if ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMissionText(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsSupportNotice(t *testing.T) {
+	tests := map[string]bool{
+		"Use code iferal to support us!":                  true,
+		"use code SOMEONEELSE in the item shop":           true,
+		"Use Creator Code stwdaily when buying V-Bucks":   true,
+		"Remember to Support-a-Creator: stwdaily":         true,
+		"80 140Ride the Lightning in Twine Peaks":         false,
+		"50 64Retrieve the Data in Plankerton":            false,
+		"40 100Deliver the Bomb in Codename Unused Canny": false,
+	}
+	for text, want := range tests {
+		if got := isSupportNotice(text); got != want {
+			t.Errorf("isSupportNotice(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestParseMissionsHTMLSkipsOtherCreatorCodes(t *testing.T) {
+	page := `<div class="news-link">
+		<div class="infonotice">Use code NEWCREATOR2025 to support the site in Epic Games!</div>
+		<div class="infonotice">80 140Ride the Lightning in Twine Peaks</div>
+	</div>`
+	missions, err := parseMissionsHTML([]byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missions) != 1 || missions[0].Area != "Twine Peaks" {
+		t.Errorf("parseMissionsHTML = %+v, want only the Twine Peaks mission", missions)
+	}
+}
+
+func TestParseMissionsHTMLModifiers(t *testing.T) {
+	page := `<div class="news-link">
+		<div class="infonotice">80 140Ride the Lightning <img alt="Fire Storm"><img alt=" " title="Smashers"> in Twine Peaks</div>
+		<div class="infonotice">50 64Retrieve the Data in Plankerton</div>
+	</div>`
+	missions, err := parseMissionsHTML([]byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missions) != 2 {
+		t.Fatalf("parsed %d missions, want 2", len(missions))
+	}
+	if want := []string{"Fire Storm", "Smashers"}; !reflect.DeepEqual(missions[0].Modifiers, want) {
+		t.Errorf("modifiers = %q, want %q", missions[0].Modifiers, want)
+	}
+	if missions[1].Modifiers != nil {
+		t.Errorf("mission without modifiers got %q", missions[1].Modifiers)
+	}
+}
+
+// slowAndFastSources serves a JSON source answering at once and one that
+// hangs until the test ends
+func slowAndFastSources(t *testing.T) []Source {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		io.WriteString(w, `[{"Area": "Twine Peaks", "PowerLevel": "140", "Amount": "80", "MissionType": "Ride the Lightning"}]`)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	return []Source{
+		{Name: "slow", Kind: SourceKindJSON, URL: srv.URL + "/slow"},
+		{Name: "fast", Kind: SourceKindJSON, URL: srv.URL + "/fast"},
+	}
+}
+
+func TestFetchSourcesTimesOutSlowSource(t *testing.T) {
+	srcs := slowAndFastSources(t)
+	const timeout = 200 * time.Millisecond
+
+	start := time.Now()
+	results := fetchSources(srcs, timeout)
+	if took := time.Since(start); took > timeout+time.Second {
+		t.Errorf("fetchSources took %s, the slow source held it up past the %s timeout", took, timeout)
+	}
+
+	slow, fast := results[0], results[1]
+	if slow.err == nil || !strings.Contains(slow.err.Error(), "timed out") {
+		t.Errorf("slow source error = %v, want a timeout", slow.err)
+	}
+	if fast.err != nil || len(fast.missions) != 1 {
+		t.Errorf("fast source = %+v, want its mission", fast)
+	}
+	if fast.took >= timeout {
+		t.Errorf("fast source took %s, want it well under the timeout", fast.took)
+	}
+}
+
+func TestFetchMissionsSkipsTimedOutSource(t *testing.T) {
+	withSources(t, slowAndFastSources(t)...)
+	saved := sourceTimeout
+	sourceTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { sourceTimeout = saved })
+
+	missions, bySource, err := fetchMissions()
+	if err != nil {
+		t.Fatalf("fetchMissions failed although one source answered: %v", err)
+	}
+	if len(missions) != 1 || missions[0].Area != "Twine Peaks" {
+		t.Errorf("fetchMissions = %+v, want the fast source's mission", missions)
+	}
+	if _, ok := bySource["slow"]; ok {
+		t.Errorf("bySource has missions for the timed-out source: %+v", bySource)
+	}
+}
+
+func TestParseMissionsHTMLWithoutMissions(t *testing.T) {
+	// The container is still there on days without V-Bucks missions
+	empty := `<html><body><div class="news-link"></div></body></html>`
+	missions, err := parseMissionsHTML([]byte(empty))
+	if err != nil {
+		t.Fatalf("parseMissionsHTML rejected an empty missions block: %v", err)
+	}
+	if len(missions) != 0 {
+		t.Errorf("parsed %+v from an empty missions block", missions)
+	}
+
+	// A page without it means the layout changed under the parser
+	redesigned := `<html><body><section class="missions"><p>80 140Ride the Lightning in Twine Peaks</p></section></body></html>`
+	if missions, err := parseMissionsHTML([]byte(redesigned)); err == nil {
+		t.Errorf("parseMissionsHTML = %+v for a page with no missions container, want an error", missions)
+	}
+}
+
+func TestParseSelectorHTMLContainer(t *testing.T) {
+	sel := Selectors{Mission: "li.m", Area: ".a", Amount: ".v", Container: "ul.missions"}
+
+	if missions, err := parseSelectorHTML(sel, []byte(`<ul class="missions"></ul>`)); err != nil || len(missions) != 0 {
+		t.Errorf("empty container: parseSelectorHTML = %+v, %v, want no missions and no error", missions, err)
+	}
+	if _, err := parseSelectorHTML(sel, []byte(`<div class="other"></div>`)); err == nil {
+		t.Error("missing container: parseSelectorHTML accepted the page")
+	}
+
+	// Without a Container selector an empty page can't be told apart
+	sel.Container = ""
+	if _, err := parseSelectorHTML(sel, []byte(`<div class="other"></div>`)); err != nil {
+		t.Errorf("no Container selector: parseSelectorHTML = %v, want no error", err)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// ttsTimeout bounds each text-to-speech request
+	ttsTimeout = 30 * time.Second
+	// maxVoiceSize caps the audio read from the TTS backend; Telegram takes
+	// voice messages up to 1 MB as OGG/Opus and larger ones as documents
+	maxVoiceSize = 1 << 20
+)
+
+// ttsClient turns text into speech with the backend at TTS_URL
+// A nil client means TTS isn't configured
+type ttsClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// tts is set when TTS_URL is
+var tts *ttsClient
+
+// startTTS reads TTS_URL and TTS_TOKEN
+func startTTS() {
+	url := os.Getenv("TTS_URL")
+	if url == "" {
+		return
+	}
+
+	tts = &ttsClient{url: url, token: os.Getenv("TTS_TOKEN"), client: &http.Client{Timeout: ttsTimeout}}
+	log.Printf("Speaking /speak replies with %s", url)
+}
+
+// synthesize POSTs the text and returns the OGG/Opus audio the backend answers with
+func (c *ttsClient) synthesize(text string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Accept", "audio/ogg")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TTS backend answered HTTP %d", resp.StatusCode)
+	}
+	audio, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxVoiceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	if len(audio) == 0 || len(audio) > maxVoiceSize {
+		return nil, fmt.Errorf("TTS backend returned %d bytes of audio", len(audio))
+	}
+	return audio, nil
+}
+
+// smallNumbers and tens spell out numbers for spokenNumber
+var (
+	smallNumbers = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+)
+
+// spokenNumber spells out n, e.g. 1250 is "one thousand two hundred fifty"
+func spokenNumber(n int) string {
+	switch {
+	case n < 0:
+		return "minus " + spokenNumber(-n)
+	case n < 20:
+		return smallNumbers[n]
+	case n < 100:
+		if n%10 == 0 {
+			return tens[n/10]
+		}
+		return tens[n/10] + " " + smallNumbers[n%10]
+	case n < 1000:
+		if n%100 == 0 {
+			return smallNumbers[n/100] + " hundred"
+		}
+		return smallNumbers[n/100] + " hundred " + spokenNumber(n%100)
+	case n < 1000000:
+		if n%1000 == 0 {
+			return spokenNumber(n/1000) + " thousand"
+		}
+		return spokenNumber(n/1000) + " thousand " + spokenNumber(n%1000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+// spokenPowerLevel reads a power level or a range such as "76-82" aloud
+func spokenPowerLevel(level string) string {
+	min, max, ok := powerRange(level)
+	switch {
+	case !ok:
+		return level
+	case min == max:
+		return spokenNumber(min)
+	default:
+		return spokenNumber(min) + " to " + spokenNumber(max)
+	}
+}
+
+// formatSpokenMissions renders today's missions, after the chat's filters,
+// as plain sentences meant to be read aloud
+func formatSpokenMissions(prefs ChatPreferences, vbucksMissions []VBucksMission) string {
+	vbucksMissions = filterForChat(prefs, vbucksMissions)
+	if len(vbucksMissions) == 0 {
+		return "There are no V-Bucks missions today."
+	}
+
+	var b strings.Builder
+	total, _ := sumVBucks(vbucksMissions)
+	if len(vbucksMissions) == 1 {
+		fmt.Fprintf(&b, "There is one V-Bucks mission today, worth %s V-Bucks.", spokenNumber(total))
+	} else {
+		fmt.Fprintf(&b, "There are %s V-Bucks missions today, worth %s V-Bucks in total.", spokenNumber(len(vbucksMissions)), spokenNumber(total))
+	}
+	for i, mission := range vbucksMissions {
+		number := spokenNumber(i + 1)
+		fmt.Fprintf(&b, " %s: %s in %s, power level %s, %s V-Bucks.",
+			strings.ToUpper(number[:1])+number[1:], mission.MissionType, mission.Area,
+			spokenPowerLevel(mission.PowerLevel), spokenNumber(missionAmount(mission)))
+	}
+	return b.String()
+}
+
+// sendSpokenMissions handles /speak: today's missions as a voice message,
+// or as text when TTS isn't configured or fails
+func sendSpokenMissions(t *tenant, chatID int64) {
+	missions, _ := getMissions()
+	text := formatSpokenMissions(t.prefs.get(chatID), missions)
+
+	if tts == nil {
+		reply(t.bot, chatID, "Voice messages aren't set up on this bot, so here it is as text:\n\n"+text)
+		return
+	}
+
+	audio, err := tts.synthesize(text)
+	if err != nil {
+		log.Printf("Error synthesizing speech for chat %d: %v", chatID, err)
+		reply(t.bot, chatID, text)
+		return
+	}
+
+	voice := tgbotapi.NewVoice(chatID, tgbotapi.FileBytes{Name: "missions.ogg", Bytes: audio})
+	if _, err := t.bot.Send(voice); err != nil {
+		log.Printf("Error sending voice message to chat %d: %v", chatID, err)
+		reply(t.bot, chatID, text)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// Metric names for the counters and gauges pushed to StatsD
+const (
+	metricScrapes        = "scrapes"
+	metricScrapeFailures = "scrape_failures"
+	metricScrapeDuration = "scrape_duration"
+	metricMissions       = "missions"
+	metricMessagesSent   = "messages_sent"
+	metricMessagesFailed = "messages_failed"
+)
+
+// statsdPrefix is put in front of every metric name pushed to StatsD
+const statsdPrefix = "stw."
+
+// statsdClient pushes metrics to a StatsD agent over UDP
+// A nil client is a no-op, so callers don't need to check whether it's enabled
+type statsdClient struct {
+	conn net.Conn
+}
+
+// statsd is set when STATSD_ADDR is
+var statsd *statsdClient
+
+// startStatsd connects to STATSD_ADDR, if it's set
+func startStatsd() error {
+	addr := os.Getenv("STATSD_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to StatsD at %s: %v", addr, err)
+	}
+	statsd = &statsdClient{conn: conn}
+
+	log.Printf("Pushing metrics to StatsD at %s", addr)
+	return nil
+}
+
+// push sends one metric line; UDP is fire-and-forget so errors are dropped
+func (c *statsdClient) push(name, value, kind string) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.conn, "%s%s:%s|%s", statsdPrefix, name, value, kind)
+}
+
+// count adds n to a counter
+func (c *statsdClient) count(name string, n int) {
+	c.push(name, fmt.Sprint(n), "c")
+}
+
+// gauge sets a gauge to v
+func (c *statsdClient) gauge(name string, v int) {
+	c.push(name, fmt.Sprint(v), "g")
+}
+
+// timing records a duration in milliseconds
+func (c *statsdClient) timing(name string, d time.Duration) {
+	c.push(name, fmt.Sprint(d.Milliseconds()), "ms")
+}
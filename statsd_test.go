@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenStatsd starts StatsD on a local UDP listener for the test and returns
+// a function reading the packets received so far
+func listenStatsd(t *testing.T) func() []string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	t.Setenv("STATSD_ADDR", conn.LocalAddr().String())
+	if err := startStatsd(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		statsd.conn.Close()
+		statsd = nil
+	})
+
+	return func() []string {
+		var packets []string
+		buf := make([]byte, 1024)
+		for {
+			conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return packets
+			}
+			packets = append(packets, string(buf[:n]))
+		}
+	}
+}
+
+func TestStatsdPackets(t *testing.T) {
+	received := listenStatsd(t)
+
+	statsd.count(metricMessagesSent, 2)
+	statsd.gauge(metricMissions, 7)
+	statsd.timing(metricScrapeDuration, 1500*time.Millisecond)
+
+	want := []string{"stw.messages_sent:2|c", "stw.missions:7|g", "stw.scrape_duration:1500|ms"}
+	got := received()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("packets = %q, want %q", got, want)
+	}
+}
+
+func TestStatsdScrapeMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"Area": "Twine Peaks", "PowerLevel": "140", "Amount": "80", "MissionType": "Ride the Lightning"}]`)
+	}))
+	defer srv.Close()
+	withSources(t, Source{Name: "api", Kind: SourceKindJSON, URL: srv.URL})
+	received := listenStatsd(t)
+
+	if _, _, err := fetchMissions(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(received(), "\n")
+	for _, want := range []string{"stw.scrapes:1|c", "stw.missions:1|g", "stw.scrape_duration:"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scrape sent %q, want a %q packet", got, want)
+		}
+	}
+	if strings.Contains(got, metricScrapeFailures) {
+		t.Errorf("successful scrape counted a failure: %q", got)
+	}
+}
+
+func TestStatsdDisabled(t *testing.T) {
+	t.Setenv("STATSD_ADDR", "")
+	if err := startStatsd(); err != nil || statsd != nil {
+		t.Fatalf("startStatsd without STATSD_ADDR = %v, client %v", err, statsd)
+	}
+	// A nil client is a no-op
+	statsd.count(metricScrapes, 1)
+}
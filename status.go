@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slowScrapeThreshold is the scrape duration above which the source is
+// reported as degraded
+const slowScrapeThreshold = 10 * time.Second
+
+// SourceStatus is how the most recent fetch from one source went
+type SourceStatus struct {
+	LastSuccess    time.Time
+	LastError      string
+	LastHTTPStatus int
+	LastCount      int
+	LastLatency    time.Duration
+	// NextReset is the mission reset the source's page stated, if any
+	NextReset time.Time
+	// LayoutChanged is set when the last fetch found no missions container
+	LayoutChanged bool
+}
+
+// FetchStatus tracks the health of scraping across fetches
+// All updates go through its record methods and all reads through its
+// getters, so it's safe to share between the bots and the notifier
+type FetchStatus struct {
+	mu                  sync.Mutex
+	lastAttempt         time.Time
+	lastSuccess         time.Time
+	lastError           error
+	consecutiveFailures int
+	lastCount           int
+	lastLatency         time.Duration
+	sources             map[string]SourceStatus
+}
+
+// NewFetchStatus returns an empty FetchStatus
+func NewFetchStatus() *FetchStatus {
+	return &FetchStatus{sources: map[string]SourceStatus{}}
+}
+
+// fetchStatus is shared by every scrape
+var fetchStatus = NewFetchStatus()
+
+// RecordHTTPStatus stores the status code a source answered with
+func (s *FetchStatus) RecordHTTPStatus(source string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.sources[source]
+	status.LastHTTPStatus = code
+	s.sources[source] = status
+}
+
+// RecordReset stores the reset time a source's page stated; zero if none
+func (s *FetchStatus) RecordReset(source string, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.sources[source]
+	status.NextReset = reset
+	s.sources[source] = status
+}
+
+// NextReset returns the earliest upcoming reset stated by any source
+func (s *FetchStatus) NextReset(now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earliest time.Time
+	for _, status := range s.sources {
+		if status.NextReset.After(now) && (earliest.IsZero() || status.NextReset.Before(earliest)) {
+			earliest = status.NextReset
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// RecordSource stores the outcome of fetching a single source
+func (s *FetchStatus) RecordSource(source string, count int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.sources[source]
+	status.LastLatency = latency
+	status.LayoutChanged = isLayoutChanged(err)
+	if err != nil {
+		status.LastError = err.Error()
+	} else {
+		status.LastError = ""
+		status.LastSuccess = time.Now().UTC()
+		status.LastCount = count
+	}
+	s.sources[source] = status
+}
+
+// RecordFetch stores the outcome of a whole fetch across all sources
+func (s *FetchStatus) RecordFetch(count int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastAttempt = time.Now().UTC()
+	s.lastLatency = latency
+	s.lastError = err
+	if err != nil {
+		s.consecutiveFailures++
+		return
+	}
+
+	s.consecutiveFailures = 0
+	s.lastSuccess = s.lastAttempt
+	s.lastCount = count
+}
+
+// LastAttempt returns when the last fetch finished, successful or not
+func (s *FetchStatus) LastAttempt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastAttempt
+}
+
+// LastSuccess returns when the last successful fetch finished
+func (s *FetchStatus) LastSuccess() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSuccess
+}
+
+// LastError returns the error of the last fetch, or nil if it succeeded
+func (s *FetchStatus) LastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastError
+}
+
+// ConsecutiveFailures returns how many fetches in a row have failed
+func (s *FetchStatus) ConsecutiveFailures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.consecutiveFailures
+}
+
+// LastCount returns how many missions the last successful fetch found
+func (s *FetchStatus) LastCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastCount
+}
+
+// LastLatency returns how long the last fetch took
+func (s *FetchStatus) LastLatency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastLatency
+}
+
+// Degraded reports whether the last fetch was slower than slowScrapeThreshold
+func (s *FetchStatus) Degraded() bool {
+	return s.LastLatency() > slowScrapeThreshold
+}
+
+// LayoutChanged returns the sources whose last fetch found no missions
+// container, sorted by name
+func (s *FetchStatus) LayoutChanged() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name, status := range s.sources {
+		if status.LayoutChanged {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sources returns a copy of the per-source status
+func (s *FetchStatus) Sources() map[string]SourceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sources := make(map[string]SourceStatus, len(s.sources))
+	for name, status := range s.sources {
+		sources[name] = status
+	}
+	return sources
+}
+
+// format renders the status as plain text for /stats
+func (s *FetchStatus) format() string {
+	lastAttempt := s.LastAttempt()
+	if lastAttempt.IsZero() {
+		return "No scrape has run since the bot started; missions are served from the cache."
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Last scrape: %s (%s ago)\n",
+		lastAttempt.Format(time.RFC3339),
+		time.Since(lastAttempt).Round(time.Second),
+	))
+
+	if err := s.LastError(); err != nil {
+		result.WriteString(fmt.Sprintf("Last error: %v (%d failures in a row)\n", err, s.ConsecutiveFailures()))
+	}
+	if lastSuccess := s.LastSuccess(); !lastSuccess.IsZero() {
+		result.WriteString(fmt.Sprintf("Last success: %s, %d missions\n", lastSuccess.Format(time.RFC3339), s.LastCount()))
+	}
+
+	result.WriteString(fmt.Sprintf("Scrape latency: %s", s.LastLatency().Round(time.Millisecond)))
+	if s.Degraded() {
+		result.WriteString(fmt.Sprintf(" (degraded, over %s)", slowScrapeThreshold))
+	}
+	result.WriteString("\n")
+
+	sources := s.Sources()
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		status := sources[name]
+		result.WriteString(fmt.Sprintf("\n%s: HTTP %d, %d missions, %s",
+			name, status.LastHTTPStatus, status.LastCount, status.LastLatency.Round(time.Millisecond)))
+		if status.LastError != "" {
+			result.WriteString(", error: " + status.LastError)
+		}
+	}
+
+	return strings.TrimSuffix(result.String(), "\n")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// formatStreak handles /streak: how many days in a row a mission type has
+// been offered up to today, and its longest run within the retained history
+// Days without recorded history break a run since nothing is known about them
+func formatStreak(h *historyStore, args string, now time.Time) string {
+	missionType := strings.TrimSpace(args)
+	if missionType == "" {
+		return "Usage: /streak <mission type>, e.g. /streak ride the lightning"
+	}
+
+	today := now.UTC().Format(dateLayout)
+	var recorded, current, longest int
+	for day := now.UTC().AddDate(0, 0, -(h.retention - 1)); day.Format(dateLayout) <= today; day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		missions, ok := h.missionsOn(date)
+		if ok {
+			recorded++
+		}
+		if ok && offersType(missions, missionType) {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else if date != today || ok {
+			// Today only breaks the run once it's been scraped
+			current = 0
+		}
+	}
+
+	if recorded == 0 {
+		return "No mission history yet. Check back after a day of scraping."
+	}
+	if longest == 0 {
+		return fmt.Sprintf("No %q V-Bucks missions in %s of recorded history.", missionType, plural(recorded, "day"))
+	}
+
+	run := fmt.Sprintf("%q V-Bucks missions have been up %s in a row.", missionType, plural(current, "day"))
+	if current == 0 {
+		run = fmt.Sprintf("%q V-Bucks missions aren't up today.", missionType)
+	}
+	return fmt.Sprintf("%s\nLongest run in the last %s of history: %s.", run, plural(h.retention, "day"), plural(longest, "day"))
+}
+
+// offersType reports whether any of the missions matches the mission type
+func offersType(missions []VBucksMission, missionType string) bool {
+	for _, mission := range missions {
+		if matchesType(mission.MissionType, missionType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// tenant is one bot instance with its own subscribers and chat preferences
+// All tenants share the same scrape and cache layer
+type tenant struct {
+	bot   *tgbotapi.BotAPI
+	prefs *preferenceStore
+	// primary is set on the bot of the first token, which posts to AREA_CHANNELS
+	primary bool
+}
+
+// running holds every tenant that finished connecting, for fan-outs that
+// span all bots
+var running struct {
+	mu      sync.Mutex
+	tenants []*tenant
+}
+
+// runningTenants returns the connected tenants
+func runningTenants() []*tenant {
+	running.mu.Lock()
+	defer running.mu.Unlock()
+
+	return append([]*tenant(nil), running.tenants...)
+}
+
+// botTokens returns the tokens of the bots to run: the comma-separated
+// TELEGRAM_BOT_TOKENS list in multi-tenant mode, otherwise TELEGRAM_BOT_TOKEN
+func botTokens() ([]string, error) {
+	if list := os.Getenv("TELEGRAM_BOT_TOKENS"); list != "" {
+		var tokens []string
+		for _, token := range strings.Split(list, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("TELEGRAM_BOT_TOKENS doesn't contain any tokens")
+		}
+		return tokens, nil
+	}
+
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN not set in .env file")
+	}
+	return []string{token}, nil
+}
+
+const (
+	// connectRetryDelay is the first wait before retrying the Telegram connection
+	connectRetryDelay = 2 * time.Second
+	// maxConnectRetryDelay caps the backoff between connection attempts
+	maxConnectRetryDelay = 5 * time.Minute
+)
+
+// connectBot connects to Telegram, retrying with exponential backoff while
+// it's unreachable so an outage at startup doesn't kill the process
+// A token Telegram rejects is returned as an error since retrying can't help
+func connectBot(token string) (*tgbotapi.BotAPI, error) {
+	delay := connectRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		bot, err := tgbotapi.NewBotAPI(token)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Telegram reachable again after %d attempts", attempt)
+			}
+			return bot, nil
+		}
+
+		var tgErr *tgbotapi.Error
+		if errors.As(err, &tgErr) && (tgErr.Code == http.StatusUnauthorized || tgErr.Code == http.StatusNotFound) {
+			return nil, fmt.Errorf("telegram rejected the bot token: %v", err)
+		}
+
+		log.Printf("Telegram unreachable (attempt %d): %v; retrying in %s", attempt, err, delay)
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxConnectRetryDelay {
+			delay = maxConnectRetryDelay
+		}
+	}
+}
+
+// newTenant connects a bot and loads its preferences store
+// In multi-tenant mode each bot keeps its store in a file named after its ID
+func newTenant(token string, multi bool) (*tenant, error) {
+	bot, err := connectBot(token)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Authorized on account %s", bot.Self.UserName)
+
+	path := prefsFile
+	if multi {
+		path = fmt.Sprintf("chat_prefs_%d.json", bot.Self.ID)
+	}
+
+	prefs, err := loadPreferences(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading chat preferences for %s: %v", bot.Self.UserName, err)
+	}
+
+	t := &tenant{bot: bot, prefs: prefs}
+
+	running.mu.Lock()
+	running.tenants = append(running.tenants, t)
+	running.mu.Unlock()
+
+	return t, nil
+}
+
+// run registers the command menu, starts the daily notifier and handles the
+// bot's updates until the process exits
+func (t *tenant) run() {
+	// Register the command menu shown by Telegram clients
+	if err := commands.setMenu(t.bot); err != nil {
+		log.Printf("Error registering bot commands for %s: %v", t.bot.Self.UserName, err)
+	}
+
+	// Push the missions to subscribers after every daily reset
+	go runNotifier(t)
+
+	// Nudge chats at the daily time they picked with /remindat
+	go runReminders(t)
+
+	// Start listening for updates
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	updates := t.bot.GetUpdatesChan(u)
+
+	for update := range updates {
+		t.handleUpdate(update)
+	}
+}
+
+// handleUpdate handles one update
+// A panic in a handler is logged and reported to the admin chat instead of
+// taking down the bot, so the other updates keep being handled
+func (t *tenant) handleUpdate(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.reportPanic(update, r, debug.Stack())
+		}
+	}()
+
+	// Answer inline queries (@bot in any chat) with today's missions
+	if update.InlineQuery != nil {
+		answerInlineQuery(t.bot, update.InlineQuery)
+		return
+	}
+
+	// Handle presses of inline keyboard buttons
+	if update.CallbackQuery != nil {
+		handleCallback(t, update.CallbackQuery)
+		return
+	}
+
+	// Channels only get /whoami, so admins can look up the channel's ID
+	if post := update.ChannelPost; post != nil {
+		if post.IsCommand() && post.Command() == "whoami" {
+			commands.dispatch(t, post)
+		}
+		return
+	}
+
+	if update.Message == nil {
+		return
+	}
+
+	// Log the chat ID for setup purposes
+	log.Printf("[%s] Received message from chat ID: %d", t.bot.Self.UserName, update.Message.Chat.ID)
+
+	// Process commands
+	if update.Message.IsCommand() {
+		commands.dispatch(t, update.Message)
+	} else if update.Message.Text != "" {
+		handleKeyword(t, update.Message)
+	}
+}
+
+// reportPanic logs a panic raised while handling an update, with what was
+// being handled, and tells the admin chat if there is one
+func (t *tenant) reportPanic(update tgbotapi.Update, r interface{}, stack []byte) {
+	handling := fmt.Sprintf("update %d", update.UpdateID)
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		handling = fmt.Sprintf("/%s from chat %s", update.Message.Command(), chatLabels.name(update.Message.Chat.ID))
+	case update.Message != nil:
+		handling = fmt.Sprintf("a message from chat %s", chatLabels.name(update.Message.Chat.ID))
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		handling = fmt.Sprintf("button %q in chat %s", update.CallbackQuery.Data, chatLabels.name(update.CallbackQuery.Message.Chat.ID))
+	case update.InlineQuery != nil:
+		handling = "an inline query"
+	}
+	log.Printf("[%s] Panic handling %s: %v\n%s", t.bot.Self.UserName, handling, r, stack)
+
+	configMu.RLock()
+	admin := adminChatID
+	configMu.RUnlock()
+	if admin != 0 {
+		reply(t.bot, admin, fmt.Sprintf("⚠️ Panic handling %s: %v. The stack trace is in the log.", handling, r))
+	}
+}
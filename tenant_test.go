@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// panicCommand is a Command whose handler always panics
+type panicCommand struct{}
+
+func (panicCommand) Spec() commandSpec { return commandSpec{Name: "testpanic", Description: "Panic"} }
+
+func (panicCommand) Run(t *tenant, msg *tgbotapi.Message) { panic("handler bug") }
+
+// addCommand registers a command with the bot's registry for the test
+func addCommand(t *testing.T, cmd Command) {
+	t.Helper()
+	commands.add(cmd)
+	t.Cleanup(func() {
+		delete(commands.byName, cmd.Spec().Name)
+		commands.order = commands.order[:len(commands.order)-1]
+	})
+}
+
+func TestHandleUpdateRecoversFromPanics(t *testing.T) {
+	withAdmin(t, 100)
+	addCommand(t, panicCommand{})
+	echo := &fakeCommand{spec: commandSpec{Name: "testecho", Description: "Echo"}}
+	addCommand(t, echo)
+
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+
+	// handleUpdate has to return instead of taking the polling loop down
+	tn.handleUpdate(tgbotapi.Update{UpdateID: 1, Message: commandMessage(7, "/testpanic")})
+	tn.handleUpdate(tgbotapi.Update{UpdateID: 2, Message: commandMessage(7, "/testecho after")})
+
+	if len(echo.ran) != 1 || echo.ran[0] != "/testecho after" {
+		t.Errorf("the update after the panic ran %q, want /testecho after", echo.ran)
+	}
+
+	sent := fake.sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %q, want one report to the admin chat", sent)
+	}
+	if !strings.Contains(sent[0], "/testpanic from chat 7") || !strings.Contains(sent[0], "handler bug") {
+		t.Errorf("panic report = %q", sent[0])
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if chatID := fake.requests[0].Form.Get("chat_id"); chatID != "100" {
+		t.Errorf("panic report went to chat %s, want the admin chat", chatID)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// formatTiers handles /tiers: today's missions counted by reward, highest
+// reward first, e.g. "80 V-Bucks: 1 mission, 50 V-Bucks: 2 missions."
+func formatTiers(vbucksMissions []VBucksMission) string {
+	if len(vbucksMissions) == 0 {
+		return "No V-Bucks missions found today."
+	}
+
+	counts := map[int]int{}
+	for _, mission := range vbucksMissions {
+		counts[missionAmount(mission)]++
+	}
+
+	amounts := make([]int, 0, len(counts))
+	for amount := range counts {
+		amounts = append(amounts, amount)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(amounts)))
+
+	tiers := make([]string, len(amounts))
+	for i, amount := range amounts {
+		tiers[i] = fmt.Sprintf("%d V-Bucks: %s", amount, plural(counts[amount], "mission"))
+	}
+	return strings.Join(tiers, ", ") + "."
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// missionDateLayouts are the date formats sources list mission days in
+// Layouts without a year are taken to mean the next such day
+var missionDateLayouts = []string{
+	dateLayout,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2",
+	"Jan 2",
+	"2 January",
+	"2 Jan",
+}
+
+// normalizeMissionDate turns the date a source lists a mission for into
+// YYYY-MM-DD, empty when there's none or it can't be read
+func normalizeMissionDate(raw string, now time.Time) string {
+	raw = strings.Join(strings.Fields(raw), " ")
+	if raw == "" {
+		return ""
+	}
+
+	now = now.UTC()
+	for _, layout := range missionDateLayouts {
+		date, err := time.Parse(layout, raw)
+		if err != nil {
+			continue
+		}
+		if date.Year() == 0 {
+			date = date.AddDate(now.Year(), 0, 0)
+			// A day more than a month ago is next year's
+			if date.Before(now.AddDate(0, -1, 0)) {
+				date = date.AddDate(1, 0, 0)
+			}
+		}
+		return date.Format(dateLayout)
+	}
+
+	log.Printf("Couldn't read mission date %q, treating the mission as today's", raw)
+	return ""
+}
+
+// splitUpcoming separates the missions dated after now's day from the rest,
+// which are today's
+func splitUpcoming(vbucksMissions []VBucksMission, now time.Time) (today, upcoming []VBucksMission) {
+	date := now.UTC().Format(dateLayout)
+	for _, mission := range vbucksMissions {
+		if mission.Date > date {
+			upcoming = append(upcoming, mission)
+		} else {
+			today = append(today, mission)
+		}
+	}
+	return today, upcoming
+}
+
+// formatUpcoming handles /upcoming: the future-dated missions from the last
+// scrape, grouped by day
+func formatUpcoming(upcoming []VBucksMission, now time.Time) string {
+	// The cache may hold days that have arrived since it was written
+	_, upcoming = splitUpcoming(upcoming, now)
+	if len(upcoming) == 0 {
+		return "The source only lists today's missions right now. /vbucks shows them."
+	}
+
+	byDate := map[string][]VBucksMission{}
+	var dates []string
+	for _, mission := range upcoming {
+		if _, ok := byDate[mission.Date]; !ok {
+			dates = append(dates, mission.Date)
+		}
+		byDate[mission.Date] = append(byDate[mission.Date], mission)
+	}
+	sort.Strings(dates)
+
+	var result strings.Builder
+	result.WriteString("Upcoming V-Bucks missions:\n")
+	for _, date := range dates {
+		missions := byDate[date]
+		total, estimated := sumVBucks(missions)
+		result.WriteString(fmt.Sprintf("\n%s, %d V-Bucks%s:\n", date, total, estimateNote(estimated)))
+		for _, mission := range missions {
+			result.WriteString("- " + missionLine(mission) + "\n")
+		}
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
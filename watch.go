@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// watchKeep leaves watches in place after they fire when WATCH_KEEP=1
+var watchKeep bool
+
+// matchesType reports whether a mission type fuzzy-matches a watched type,
+// ignoring case and allowing partial names
+func matchesType(missionType, watched string) bool {
+	return strings.Contains(strings.ToLower(missionType), strings.ToLower(watched))
+}
+
+// notifyWatchers pings every chat watching a mission type that appears today
+func notifyWatchers(t *tenant, vbucksMissions []VBucksMission) {
+	configMu.RLock()
+	keep := watchKeep
+	configMu.RUnlock()
+
+	for chatID, prefs := range t.prefs.all() {
+		if len(prefs.WatchedTypes) == 0 {
+			continue
+		}
+
+		var fired []string
+		var result strings.Builder
+		for _, watched := range prefs.WatchedTypes {
+			var lines []string
+			for _, mission := range vbucksMissions {
+				if matchesType(mission.MissionType, watched) {
+					lines = append(lines, missionLine(mission))
+				}
+			}
+			if len(lines) == 0 {
+				continue
+			}
+
+			fired = append(fired, watched)
+			result.WriteString(fmt.Sprintf("👀 %s is up today:\n%s\n\n", watched, strings.Join(lines, "\n")))
+		}
+
+		if len(fired) == 0 {
+			continue
+		}
+
+		if !keep {
+			result.WriteString("These watches are now removed; use /watchtype to set them again.")
+		}
+		reply(t.bot, chatID, strings.TrimSpace(result.String()))
+
+		if !keep {
+			if err := t.prefs.update(chatID, func(p *ChatPreferences) {
+				p.WatchedTypes = removeTypes(p.WatchedTypes, fired)
+			}); err != nil {
+				log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+			}
+		}
+	}
+}
+
+// removeTypes returns types without any of the removed entries
+func removeTypes(types, removed []string) []string {
+	var kept []string
+	for _, watched := range types {
+		drop := false
+		for _, r := range removed {
+			if strings.EqualFold(watched, r) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, watched)
+		}
+	}
+	return kept
+}
+
+// setWatchType handles /watchtype and returns the reply text
+func setWatchType(store *preferenceStore, chatID int64, args string) string {
+	watched := strings.Trim(strings.TrimSpace(args), `"'“”`)
+
+	if watched == "" {
+		current := store.get(chatID).WatchedTypes
+		if len(current) == 0 {
+			return "Usage: /watchtype <mission type>, e.g. /watchtype \"Ride the Lightning\". You'll get a ping the next day it appears."
+		}
+		return "Watching: " + strings.Join(current, ", ") + "\nUse /unwatchtype <mission type> to stop."
+	}
+
+	for _, existing := range store.get(chatID).WatchedTypes {
+		if strings.EqualFold(existing, watched) {
+			return fmt.Sprintf("Already watching %q.", existing)
+		}
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) {
+		p.WatchedTypes = append(p.WatchedTypes, watched)
+	}); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your watch couldn't be saved. Please try again later."
+	}
+
+	return fmt.Sprintf("Watching %q. You'll get a ping the next day it appears.", watched)
+}
+
+// unsetWatchType handles /unwatchtype and returns the reply text
+func unsetWatchType(store *preferenceStore, chatID int64, args string) string {
+	watched := strings.Trim(strings.TrimSpace(args), `"'“”`)
+	if watched == "" {
+		return "Usage: /unwatchtype <mission type>"
+	}
+
+	before := len(store.get(chatID).WatchedTypes)
+	if err := store.update(chatID, func(p *ChatPreferences) {
+		p.WatchedTypes = removeTypes(p.WatchedTypes, []string{watched})
+	}); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your watch couldn't be removed. Please try again later."
+	}
+
+	if len(store.get(chatID).WatchedTypes) == before {
+		return fmt.Sprintf("You weren't watching %q.", watched)
+	}
+	return fmt.Sprintf("Stopped watching %q.", watched)
+}
+
+// loadWatchConfig reads WATCH_KEEP
+func loadWatchConfig() {
+	watchKeep = os.Getenv("WATCH_KEEP") == "1"
+}
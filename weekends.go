@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// isWeekendFor reports whether it's Saturday or Sunday at now in the chat's
+// time zone
+func isWeekendFor(prefs ChatPreferences, now time.Time) bool {
+	switch now.In(chatLocation(prefs)).Weekday() {
+	case time.Saturday, time.Sunday:
+		return true
+	}
+	return false
+}
+
+// weekendDates keeps the dates that fall on a Saturday or Sunday
+func weekendDates(dates []string) []string {
+	var weekends []string
+	for _, date := range dates {
+		day, err := time.Parse(dateLayout, date)
+		if err == nil && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			weekends = append(weekends, date)
+		}
+	}
+	return weekends
+}
+
+// setWeekendsOnly handles /weekendsonly and returns the reply text
+func setWeekendsOnly(store *preferenceStore, chatID int64, args string) string {
+	var weekends bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		weekends = true
+	case "off":
+		weekends = false
+	default:
+		return "Usage: /weekendsonly on or /weekendsonly off"
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.WeekendsOnly = weekends }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if weekends {
+		return "You'll only get the daily missions on Saturdays and Sundays, in your /timezone."
+	}
+	return "You'll get the daily missions every day."
+}
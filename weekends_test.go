@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsWeekendFor(t *testing.T) {
+	// Friday 23:30 UTC is already Saturday in Tokyo, Sunday 23:30 UTC is
+	// Monday there and still Sunday in Los Angeles
+	friday := time.Date(2024, 3, 8, 23, 30, 0, 0, time.UTC)
+	sunday := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		timezone string
+		now      time.Time
+		want     bool
+	}{
+		{timezone: "", now: friday, want: false},
+		{timezone: "Asia/Tokyo", now: friday, want: true},
+		{timezone: "", now: sunday, want: true},
+		{timezone: "Asia/Tokyo", now: sunday, want: false},
+		{timezone: "America/Los_Angeles", now: sunday, want: true},
+	}
+	for _, tt := range tests {
+		if got := isWeekendFor(ChatPreferences{Timezone: tt.timezone}, tt.now); got != tt.want {
+			t.Errorf("isWeekendFor(%q, %s) = %v, want %v", tt.timezone, tt.now.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestWeekendDates(t *testing.T) {
+	dates := []string{"2024-03-07", "2024-03-08", "2024-03-09", "2024-03-10", "2024-03-11", "not a date"}
+	if got, want := weekendDates(dates), []string{"2024-03-09", "2024-03-10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("weekendDates = %q, want %q", got, want)
+	}
+}
+
+func TestBroadcastSkipsWeekdaysForWeekendsOnly(t *testing.T) {
+	inTempDir(t)
+	fake, bot := newFakeTelegram(t)
+	tn := &tenant{bot: bot, prefs: newTestStore(t)}
+	for chatID, weekends := range map[int64]bool{1: false, 2: true} {
+		if err := tn.prefs.update(chatID, func(p *ChatPreferences) {
+			p.Subscribed, p.WeekendsOnly = true, weekends
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broadcastMissions(tn, testMissions, Freshness{UpdatedAt: time.Now()})
+
+	sentTo := map[string]bool{}
+	fake.mu.Lock()
+	for _, req := range fake.requests {
+		sentTo[req.Form.Get("chat_id")] = true
+	}
+	fake.mu.Unlock()
+
+	if !sentTo["1"] {
+		t.Error("the chat getting every day wasn't notified")
+	}
+	weekend := isWeekendFor(ChatPreferences{}, time.Now())
+	if sentTo["2"] != weekend {
+		t.Errorf("weekends-only chat notified = %v on a day with weekend = %v", sentTo["2"], weekend)
+	}
+}
+
+func TestSetWeekendsOnly(t *testing.T) {
+	store := newTestStore(t)
+	if got := setWeekendsOnly(store, 1, "on"); !strings.Contains(got, "Saturdays and Sundays") {
+		t.Errorf("/weekendsonly on = %q", got)
+	}
+	if !store.get(1).WeekendsOnly {
+		t.Error("/weekendsonly on didn't save")
+	}
+	if got := setWeekendsOnly(store, 1, "maybe"); !strings.HasPrefix(got, "Usage:") {
+		t.Errorf("/weekendsonly maybe = %q, want the usage", got)
+	}
+	setWeekendsOnly(store, 1, "off")
+	if store.get(1).WeekendsOnly {
+		t.Error("/weekendsonly off didn't save")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// defaultWelcome is the /start greeting used when no custom one is configured
+const defaultWelcome = "Welcome to the Fortnite V-Bucks Missions Bot!\n\n" +
+	"This bot will notify you of daily V-Bucks missions in Fortnite Save the World.\n\n" +
+	"Here are today's missions:"
+
+var (
+	// welcomeMessage is the /start greeting
+	welcomeMessage = defaultWelcome
+	// welcomeMarkdown sends the greeting with MarkdownV2 parse mode
+	welcomeMarkdown bool
+)
+
+// loadWelcome reads the custom greeting from WELCOME_MESSAGE or the file named
+// by WELCOME_FILE, keeping the default when neither is set
+func loadWelcome() error {
+	welcomeMarkdown = os.Getenv("WELCOME_MARKDOWN") == "1"
+
+	text := os.Getenv("WELCOME_MESSAGE")
+	if path := os.Getenv("WELCOME_FILE"); text == "" && path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read WELCOME_FILE: %v", err)
+		}
+		text = string(data)
+	}
+
+	// .env values can't hold real newlines, so accept \n escapes
+	text = strings.TrimSpace(strings.ReplaceAll(text, `\n`, "\n"))
+	if text == "" {
+		welcomeMessage = defaultWelcome
+		welcomeMarkdown = false
+		return nil
+	}
+
+	if welcomeMarkdown {
+		if err := validateMarkdownV2(text); err != nil {
+			return fmt.Errorf("welcome message is not valid MarkdownV2: %v", err)
+		}
+	}
+
+	welcomeMessage = text
+	return nil
+}
+
+// validateMarkdownV2 checks that reserved characters are escaped and that
+// formatting markers are balanced, since Telegram rejects the whole message
+// otherwise
+func validateMarkdownV2(text string) error {
+	open := map[rune]bool{}
+	escaped := false
+
+	for i, c := range text {
+		if escaped {
+			escaped = false
+			continue
+		}
+
+		switch c {
+		case '\\':
+			escaped = true
+		case '*', '_', '~', '`', '|':
+			open[c] = !open[c]
+		case '.', '!', '#', '+', '-', '=', '{', '}', '>', '[', ']', '(', ')':
+			return fmt.Errorf("unescaped %q at offset %d", c, i)
+		}
+	}
+
+	if escaped {
+		return fmt.Errorf("trailing backslash")
+	}
+	for c, isOpen := range open {
+		if isOpen {
+			return fmt.Errorf("unclosed %q", c)
+		}
+	}
+
+	return nil
+}
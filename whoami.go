@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// formatWhoAmI handles /whoami: the IDs needed to set the bot up, e.g. the
+// chat ID to put in ADMIN_CHAT_ID
+func formatWhoAmI(msg *tgbotapi.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Chat ID: %d\n", msg.Chat.ID)
+	fmt.Fprintf(&b, "Chat type: %s\n", msg.Chat.Type)
+
+	// Channel posts and anonymous group admins are sent on behalf of a chat,
+	// so there's no user ID to show
+	if msg.From != nil && msg.SenderChat == nil {
+		fmt.Fprintf(&b, "Your user ID: %d\n", msg.From.ID)
+	} else {
+		b.WriteString("Your user ID: hidden (posted as the chat)\n")
+	}
+
+	if isAdmin(msg.Chat.ID) {
+		b.WriteString("Admin chat: yes")
+	} else {
+		fmt.Fprintf(&b, "Admin chat: no (set ADMIN_CHAT_ID=%d to make it one)", msg.Chat.ID)
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultWorthThreshold is the V-Bucks total /worth calls worth playing for
+// chats without a threshold or an average of their own
+const defaultWorthThreshold = 100
+
+// worthThreshold is the global /worth threshold, set with WORTH_THRESHOLD
+var worthThreshold = defaultWorthThreshold
+
+// loadWorthThreshold reads WORTH_THRESHOLD
+func loadWorthThreshold() error {
+	v := os.Getenv("WORTH_THRESHOLD")
+	if v == "" {
+		worthThreshold = defaultWorthThreshold
+		return nil
+	}
+
+	threshold, err := strconv.Atoi(v)
+	if err != nil || threshold < 1 {
+		return fmt.Errorf("invalid WORTH_THRESHOLD %q: must be a positive number of V-Bucks", v)
+	}
+	worthThreshold = threshold
+	return nil
+}
+
+// formatWorth answers /worth: whether today's missions beat the chat's own
+// threshold, its rolling average, or the global threshold, in that order
+func formatWorth(prefs ChatPreferences, missions []VBucksMission) string {
+	total := chatTotal(prefs, missions)
+
+	configMu.RLock()
+	bar, usual := float64(worthThreshold), "the usual bar"
+	configMu.RUnlock()
+	switch {
+	case prefs.WorthThreshold > 0:
+		bar, usual = float64(prefs.WorthThreshold), "your bar"
+	case prefs.AverageDays > 0:
+		bar, usual = prefs.AverageTotal, "your usual"
+	}
+
+	if float64(total) > bar {
+		return fmt.Sprintf("Yes — %d V-Bucks today, above %s (%.0f).", total, usual, bar)
+	}
+	if total == 0 {
+		return "Nope — no V-Bucks missions for you today."
+	}
+	return fmt.Sprintf("Meh — only %d V-Bucks today, %s is %.0f.", total, usual, bar)
+}
+
+// handleWorth handles /worth: with no arguments it answers the question and
+// adds today to the chat's average, with a number it sets the chat's own
+// threshold, and "off" goes back to the average
+func handleWorth(store *preferenceStore, chatID int64, args string) string {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	if arg == "" {
+		prefs := store.get(chatID)
+		missions, _ := getMissions()
+		text := formatWorth(prefs, missions)
+
+		total := chatTotal(prefs, missions)
+		today := time.Now().UTC().Format(dateLayout)
+		if err := store.update(chatID, func(p *ChatPreferences) { addToAverage(p, today, total) }); err != nil {
+			log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		}
+		return text
+	}
+
+	threshold := 0
+	if arg != "off" {
+		var err error
+		threshold, err = strconv.Atoi(arg)
+		if err != nil || threshold < 1 {
+			return "Usage: /worth, /worth <V-Bucks> to set your own bar, or /worth off to compare with your average"
+		}
+	}
+
+	if err := store.update(chatID, func(p *ChatPreferences) { p.WorthThreshold = threshold }); err != nil {
+		log.Printf("Error saving preferences for chat %d: %v", chatID, err)
+		return "Sorry, your preference couldn't be saved. Please try again later."
+	}
+
+	if threshold == 0 {
+		return "/worth will compare today with your usual V-Bucks."
+	}
+	return fmt.Sprintf("/worth will say yes on days with more than %d V-Bucks.", threshold)
+}